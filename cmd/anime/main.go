@@ -2,16 +2,133 @@ package main
 
 import (
 	"anime/internal/database"
+	"anime/internal/embeddings"
 	"anime/internal/handlers"
+	"anime/internal/repository"
+	"anime/internal/service"
+	"anime/internal/utils"
+	"anime/internal/vectorindex"
+	"cmp"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+func newRepository() (repository.AnimeRepository, repository.CheckpointStore) {
+	switch os.Getenv("ANIME_REPOSITORY") {
+	case "memory":
+		log.Println("Using in-memory anime repository")
+		return repository.NewMemoryAnimeRepository(), repository.NewMemoryCheckpointStore()
+	default:
+		database.InitMongoDB()
+		collection := database.NewAnimeCollection
+		if name := os.Getenv("MONGO_ANIME_COLLECTION"); name != "" {
+			collection = database.Collection(name)
+		}
+		vectorIndex := cmp.Or(os.Getenv("MONGO_VECTOR_INDEX"), "new_embeddings_vector_index")
+		repo := repository.NewMongoAnimeRepository(collection, vectorIndex)
+		log.Printf("Serving animes from MongoDB collection %q", collection.Name())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		warnLegacyCollection(ctx, collection)
+		if err := repo.EnsureIndexes(ctx); err != nil {
+			log.Println("Error creating MongoDB indexes:", err)
+		}
+		if err := repo.InspectVectorIndex(ctx); err != nil {
+			log.Println("Error reading the vector index definition, filtering on the embedding model after retrieval:", err)
+		}
+
+		return repo, repository.NewMongoCheckpointStore(database.CheckpointCollection)
+	}
+}
+
+// warnLegacyCollection flags deployments that still have data in the
+// animes collection, which catalog reads came from before every read moved
+// to the collection ingestion writes to.
+func warnLegacyCollection(ctx context.Context, collection *mongo.Collection) {
+	legacy := database.AnimeCollection
+	if collection.Name() == legacy.Name() {
+		return
+	}
+	count, err := legacy.EstimatedDocumentCount(ctx)
+	if err != nil || count == 0 {
+		return
+	}
+	log.Printf("The legacy %q collection holds %d animes that are no longer served; set MONGO_ANIME_COLLECTION=%s to keep serving them, or merge them into %q (see README)",
+		legacy.Name(), count, legacy.Name(), collection.Name())
+}
+
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func envFloat(name string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func rankingWeights() service.RankingWeights {
+	defaults := service.DefaultRankingWeights()
+	return service.RankingWeights{
+		Similarity: envFloat("RANK_WEIGHT_SIMILARITY", defaults.Similarity),
+		Quality:    envFloat("RANK_WEIGHT_QUALITY", defaults.Quality),
+		Popularity: envFloat("RANK_WEIGHT_POPULARITY", defaults.Popularity),
+		Recency:    envFloat("RANK_WEIGHT_RECENCY", defaults.Recency),
+	}
+}
+
+func pipelineOptions() service.PipelineOptions {
+	defaults := service.DefaultPipelineOptions()
+	return service.PipelineOptions{
+		FetchConcurrency: envInt("INGEST_FETCH_CONCURRENCY", defaults.FetchConcurrency),
+		EmbedConcurrency: envInt("INGEST_EMBED_CONCURRENCY", defaults.EmbedConcurrency),
+		InsertBatchSize:  envInt("INGEST_BATCH_SIZE", defaults.InsertBatchSize),
+		WriteTimeout:     time.Duration(envInt("INGEST_WRITE_TIMEOUT", int(defaults.WriteTimeout/time.Second))) * time.Second,
+	}
+}
+
+func withVectorIndex(repo repository.AnimeRepository, embedder embeddings.Embedder) repository.AnimeRepository {
+	if os.Getenv("VECTOR_INDEX") != "hnsw" {
+		return repo
+	}
+
+	defaults := vectorindex.DefaultConfig()
+	indexed, err := repository.NewIndexedAnimeRepository(context.Background(), repo, repository.IndexOptions{
+		Config: vectorindex.Config{
+			M:              envInt("HNSW_M", defaults.M),
+			EfConstruction: envInt("HNSW_EF_CONSTRUCTION", defaults.EfConstruction),
+			EfSearch:       envInt("HNSW_EF_SEARCH", defaults.EfSearch),
+			Seed:           defaults.Seed,
+		},
+		Model:   embedder.ModelID(),
+		Version: embeddings.Version(embedder),
+		Path:    os.Getenv("VECTOR_INDEX_PATH"),
+	})
+	if err != nil {
+		log.Fatal("Error building vector index: ", err)
+	}
+
+	go indexed.AutoSave(context.Background(), time.Minute)
+	return indexed
+}
+
 func main() {
 
 	err := godotenv.Load()
@@ -19,22 +136,70 @@ func main() {
 		fmt.Println("Error loading .env file:", err)
 	}
 
-	database.InitMongoDB()
+	embedder, err := embeddings.NewFromEnv()
+	if err != nil {
+		log.Fatal("Error creating embedder: ", err)
+	}
+	log.Println("Using embedding model", embedder.ModelID())
+
+	anilist := utils.NewAniListClient(envInt("ANILIST_RATE_LIMIT", utils.DefaultAniListRateLimit))
+	if url := os.Getenv("ANILIST_URL"); url != "" {
+		anilist.Endpoint = url
+	}
+
+	repo, checkpoints := newRepository()
+	repo = withVectorIndex(repo, embedder)
+
+	animeService := service.NewAnimeService(repo, embedder, checkpoints, anilist)
+	animeService.SetRankingWeights(rankingWeights())
+	animeService.SetPipelineOptions(pipelineOptions())
+	if err := animeService.RebuildTitleIndex(context.Background()); err != nil {
+		log.Println("Error building title index:", err)
+	}
+	h := handlers.NewAnimeHandler(repo, animeService, anilist)
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	v1r := chi.NewRouter()
 
 	v1r.Route("/anime", func(animeRouter chi.Router) {
-		animeRouter.Get("/recommend", handlers.RecommendHandler)
-		animeRouter.Get("/new-recommend", handlers.NewRecommendHandler)
-		animeRouter.Get("/", handlers.AnimeByNameHandler)
-		animeRouter.Get("/list", handlers.AnimeListHandler)
-		animeRouter.Get("/random", handlers.RandomAnimeHandler)
-		animeRouter.Get("/top-rated", handlers.TopRatedAnimesHandler)
-		animeRouter.Get("/graphql", handlers.GraphQLAPIHandler)
-		animeRouter.Get("/insert", handlers.InsertAnimeHandler)
-		animeRouter.Get("/insertconcurrent", handlers.InsertAnimeConcurrentHandler)
+		animeRouter.Get("/recommend", h.RecommendHandler)
+		animeRouter.Post("/recommend", h.RecommendFromSeedsHandler)
+		animeRouter.Get("/new-recommend", h.NewRecommendHandler)
+		animeRouter.Get("/", h.AnimeByNameHandler)
+		animeRouter.Get("/list", h.AnimeListHandler)
+		animeRouter.Get("/search", h.SearchHandler)
+		animeRouter.Get("/suggest", h.SuggestHandler)
+		animeRouter.Get("/random", h.RandomAnimeHandler)
+		animeRouter.Get("/top-rated", h.TopRatedAnimesHandler)
+		animeRouter.Get("/graphql", h.GraphQLAPIHandler)
+		animeRouter.Get("/insert", h.InsertAnimeHandler)
+		animeRouter.Get("/insertconcurrent", h.InsertAnimeConcurrentHandler)
+		animeRouter.Post("/batch", h.BatchAnimeHandler)
+		animeRouter.Get("/{id}", h.AnimeByIDHandler)
+		animeRouter.Get("/{id}/similar", h.SimilarAnimeHandler)
+	})
+
+	v1r.Route("/genres", func(genreRouter chi.Router) {
+		genreRouter.Get("/", h.GenresHandler)
+		genreRouter.Get("/{genre}/anime", h.GenreAnimeHandler)
+	})
+
+	v1r.Route("/studios", func(studioRouter chi.Router) {
+		studioRouter.Get("/", h.StudiosHandler)
+		studioRouter.Get("/{name}/anime", h.StudioAnimeHandler)
+	})
+
+	v1r.Get("/seasons/{year}/{season}", h.SeasonAnimeHandler)
+
+	v1r.Route("/admin", func(adminRouter chi.Router) {
+		adminRouter.Get("/embeddings", h.EmbeddingReportHandler)
+		adminRouter.Get("/reembed", h.ReembedStatusHandler)
+		adminRouter.Post("/reembed", h.StartReembedHandler)
+		adminRouter.Get("/index/recall", h.IndexRecallHandler)
+		adminRouter.Get("/sync", h.SyncStatusHandler)
+		adminRouter.Post("/sync", h.StartSyncHandler)
+		adminRouter.Get("/anilist", h.AniListBudgetHandler)
 	})
 
 	r.Mount("/v1", v1r)
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"anime/internal/vectorindex"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hnswbench measures HNSW recall and latency against an exact brute-force
+// scan on synthetic clustered vectors, to help pick M and efSearch.
+func main() {
+	n := flag.Int("n", 10000, "number of indexed vectors")
+	dims := flag.Int("dims", 768, "vector dimensions")
+	clusters := flag.Int("clusters", 50, "number of synthetic clusters")
+	queries := flag.Int("queries", 200, "number of queries")
+	k := flag.Int("k", 10, "neighbours per query")
+	m := flag.Int("m", 16, "HNSW M parameter")
+	efConstruction := flag.Int("efConstruction", 200, "HNSW efConstruction parameter")
+	efSearch := flag.String("efSearch", "16,32,64,128,256", "comma-separated efSearch values")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+
+	rng := rand.New(rand.NewSource(*seed))
+	centers := make([][]float32, *clusters)
+	for i := range centers {
+		centers[i] = randomVector(rng, *dims, nil, 1)
+	}
+
+	vectors := make(map[int][]float32, *n)
+	index := vectorindex.New(vectorindex.Config{M: *m, EfConstruction: *efConstruction, Seed: *seed})
+
+	start := time.Now()
+	for id := 1; id <= *n; id++ {
+		v := randomVector(rng, *dims, centers[rng.Intn(len(centers))], 0.3)
+		vectors[id] = v
+		if err := index.Insert(id, v, 0); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Printf("built index: n=%d dims=%d M=%d efConstruction=%d in %s\n", *n, *dims, *m, *efConstruction, time.Since(start))
+
+	qs := make([][]float32, *queries)
+	for i := range qs {
+		qs[i] = randomVector(rng, *dims, centers[rng.Intn(len(centers))], 0.3)
+	}
+
+	fmt.Printf("%-10s %-10s %-14s %-14s\n", "efSearch", "recall", "hnsw/query", "brute/query")
+	for _, s := range strings.Split(*efSearch, ",") {
+		ef, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			log.Fatalf("invalid efSearch value %q", s)
+		}
+		index.SetEfSearch(ef)
+
+		report, err := vectorindex.MeasureRecall(index, vectors, qs, *k, ef)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%-10d %-10.4f %-14s %-14s\n", ef, report.Recall, report.IndexLatency, report.BruteLatency)
+	}
+}
+
+func randomVector(rng *rand.Rand, dims int, center []float32, spread float64) []float32 {
+	v := make([]float32, dims)
+	for i := range v {
+		x := rng.NormFloat64() * spread
+		if center != nil {
+			x += float64(center[i])
+		}
+		v[i] = float32(x)
+	}
+	return v
+}
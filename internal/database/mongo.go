@@ -13,6 +13,7 @@ import (
 var MongoClient *mongo.Client
 var AnimeCollection *mongo.Collection
 var NewAnimeCollection *mongo.Collection
+var CheckpointCollection *mongo.Collection
 
 func InitMongoDB() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -25,13 +26,20 @@ func InitMongoDB() {
 	}
 
 	MongoClient = client
-	AnimeCollection = MongoClient.Database("anime_recommendation").Collection("animes")
-	NewAnimeCollection = MongoClient.Database("anime_recommendation").Collection("new_animes")
+	AnimeCollection = Collection("animes")
+	NewAnimeCollection = Collection("new_animes")
+	CheckpointCollection = Collection("checkpoints")
 
 	log.Println("Connected to MongoDB")
 
 }
 
+// Collection returns the named collection of the anime_recommendation
+// database. InitMongoDB must have connected first.
+func Collection(name string) *mongo.Collection {
+	return MongoClient.Database("anime_recommendation").Collection(name)
+}
+
 func CloseMongoDB() {
 	if MongoClient != nil {
 		if err := MongoClient.Disconnect(context.Background()); err != nil {
@@ -6,38 +6,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"time"
-
-	"google.golang.org/genai"
 )
 
-func GenerateEmbedding(query string) ([]float32, error) {
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	contents := []*genai.Content{
-		genai.NewContentFromText(query, genai.RoleUser),
-	}
-
-	result, err := client.Models.EmbedContent(ctx,
-		"gemini-embedding-001",
-		contents,
-		nil)
-
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return result.Embeddings[0].Values, nil
-}
+var ollamaClient = &http.Client{Timeout: 60 * time.Second}
 
-func GenerateEmbeddingsOllama(query string) ([]float32, error) {
+func GenerateEmbeddingsOllama(ctx context.Context, query string) ([]float32, error) {
 	ollamaURL := os.Getenv("OLLAMA_URL")
 	modelName := os.Getenv("OLLAMA_MODEL")
 
@@ -51,16 +27,21 @@ func GenerateEmbeddingsOllama(query string) ([]float32, error) {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Post(ollamaURL, "application/json", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ollamaURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ollamaClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, classifyTransport(fmt.Errorf("failed to make request: %w", err))
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ollama returned non-200 status: %s", resp.Status)
+		return nil, classifyStatus(resp.StatusCode, fmt.Errorf("ollama returned non-200 status: %s", resp.Status))
 	}
 
 	var res models.OllamaResponse
@@ -0,0 +1,103 @@
+package embeddings
+
+import (
+	"anime/internal/models"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+	Dimensions() int
+	ModelID() string
+}
+
+// Versioner is implemented by embedders whose output can change between
+// revisions of the same model.
+type Versioner interface {
+	ModelVersion() string
+}
+
+type Factory func() (Embedder, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func New(name string) (Embedder, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q (available: %v)", name, Providers())
+	}
+	return factory()
+}
+
+func NewFromEnv() (Embedder, error) {
+	provider := os.Getenv("EMBEDDING_PROVIDER")
+	if provider == "" {
+		provider = "gemini"
+	}
+	return New(provider)
+}
+
+// Info describes a vector produced by e, for stamping stored documents.
+func Info(e Embedder, vector []float32) *models.EmbeddingInfo {
+	return &models.EmbeddingInfo{
+		Model:      e.ModelID(),
+		Version:    Version(e),
+		Dimensions: len(vector),
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// Version returns the model version of e, or "" if it is not a Versioner.
+func Version(e Embedder) string {
+	if v, ok := e.(Versioner); ok {
+		return v.ModelVersion()
+	}
+	return ""
+}
+
+func embedEach(ctx context.Context, e Embedder, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		vector, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+func init() {
+	Register("gemini", func() (Embedder, error) { return NewGeminiEmbedder(), nil })
+	Register("ollama", func() (Embedder, error) { return NewOllamaEmbedder(), nil })
+	Register("hash", func() (Embedder, error) { return NewHashEmbedderFromEnv() })
+}
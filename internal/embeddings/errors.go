@@ -0,0 +1,34 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrQuotaExceeded       = errors.New("embedding provider quota exceeded")
+	ErrInvalidInput        = errors.New("invalid embedding input")
+	ErrProviderUnavailable = errors.New("embedding provider unavailable")
+)
+
+// classifyStatus wraps a provider error with the typed error matching the
+// HTTP status code the provider answered with.
+func classifyStatus(code int, err error) error {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %v", ErrQuotaExceeded, err)
+	case code == http.StatusBadRequest:
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	default:
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+}
+
+func classifyTransport(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+}
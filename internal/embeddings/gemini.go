@@ -0,0 +1,101 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+const (
+	geminiModel      = "gemini-embedding-001"
+	geminiDimensions = 3072
+)
+
+type GeminiEmbedder struct {
+	mu     sync.Mutex
+	client *genai.Client
+}
+
+func NewGeminiEmbedder() *GeminiEmbedder {
+	return &GeminiEmbedder{}
+}
+
+// getClient lazily creates the genai client and reuses it for every later
+// call. A failed creation is retried on the next request.
+func (g *GeminiEmbedder) getClient(ctx context.Context) (*genai.Client, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.client != nil {
+		return g.client, nil
+	}
+
+	client, err := genai.NewClient(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	g.client = client
+	return client, nil
+}
+
+func (g *GeminiEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := g.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (g *GeminiEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	contents := make([]*genai.Content, 0, len(texts))
+	for _, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("%w: empty text", ErrInvalidInput)
+		}
+		contents = append(contents, genai.NewContentFromText(text, genai.RoleUser))
+	}
+
+	client, err := g.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := client.Models.EmbedContent(ctx, geminiModel, contents, nil)
+	if err != nil {
+		var apiErr genai.APIError
+		if errors.As(err, &apiErr) {
+			return nil, classifyStatus(apiErr.Code, err)
+		}
+		return nil, classifyTransport(err)
+	}
+
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("%w: expected %d embeddings, got %d", ErrProviderUnavailable, len(texts), len(result.Embeddings))
+	}
+
+	vectors := make([][]float32, 0, len(result.Embeddings))
+	for _, embedding := range result.Embeddings {
+		vectors = append(vectors, embedding.Values)
+	}
+	return vectors, nil
+}
+
+func (g *GeminiEmbedder) Dimensions() int {
+	return geminiDimensions
+}
+
+func (g *GeminiEmbedder) ModelID() string {
+	return "gemini/" + geminiModel
+}
+
+func (g *GeminiEmbedder) ModelVersion() string {
+	return "001"
+}
@@ -0,0 +1,104 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const defaultHashDimensions = 512
+
+// HashEmbedder is a deterministic, offline embedder based on feature hashing
+// of word unigrams, word bigrams and character trigrams. It needs no network
+// access, so ingestion and recommendations work on an air-gapped machine.
+type HashEmbedder struct {
+	dimensions int
+}
+
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = defaultHashDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+func NewHashEmbedderFromEnv() (*HashEmbedder, error) {
+	dimensionsStr := os.Getenv("HASH_EMBEDDING_DIMENSIONS")
+	if dimensionsStr == "" {
+		return NewHashEmbedder(defaultHashDimensions), nil
+	}
+
+	dimensions, err := strconv.Atoi(dimensionsStr)
+	if err != nil || dimensions <= 0 {
+		return nil, fmt.Errorf("invalid HASH_EMBEDDING_DIMENSIONS %q", dimensionsStr)
+	}
+	return NewHashEmbedder(dimensions), nil
+}
+
+func (h *HashEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		counts["w:"+word]++
+		if i > 0 {
+			counts["b:"+words[i-1]+" "+word]++
+		}
+
+		padded := []rune("^" + word + "$")
+		for j := 0; j+3 <= len(padded); j++ {
+			counts["c:"+string(padded[j:j+3])]++
+		}
+	}
+
+	vector := make([]float32, h.dimensions)
+	for feature, count := range counts {
+		hasher := fnv.New64a()
+		hasher.Write([]byte(feature))
+		sum := hasher.Sum64()
+
+		weight := float32(1 + math.Log(float64(count)))
+		if sum&(1<<63) != 0 {
+			weight = -weight
+		}
+		vector[sum%uint64(h.dimensions)] += weight
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v * v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+
+	return vector, nil
+}
+
+func (h *HashEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedEach(ctx, h, texts)
+}
+
+func (h *HashEmbedder) Dimensions() int {
+	return h.dimensions
+}
+
+func (h *HashEmbedder) ModelID() string {
+	return fmt.Sprintf("hash/ngram-%d", h.dimensions)
+}
+
+// ModelVersion must be bumped whenever the feature extraction above changes,
+// since vectors from different revisions are not comparable.
+func (h *HashEmbedder) ModelVersion() string {
+	return "v1"
+}
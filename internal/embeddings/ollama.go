@@ -0,0 +1,47 @@
+package embeddings
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+type OllamaEmbedder struct {
+	model      string
+	dimensions atomic.Int64
+}
+
+func NewOllamaEmbedder() *OllamaEmbedder {
+	return &OllamaEmbedder{model: os.Getenv("OLLAMA_MODEL")}
+}
+
+func (o *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector, err := GenerateEmbeddingsOllama(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	o.dimensions.Store(int64(len(vector)))
+	return vector, nil
+}
+
+func (o *OllamaEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedEach(ctx, o, texts)
+}
+
+// Dimensions reports the length of the last vector Ollama returned, or 0
+// before the first call since the size depends on the configured model.
+func (o *OllamaEmbedder) Dimensions() int {
+	return int(o.dimensions.Load())
+}
+
+func (o *OllamaEmbedder) ModelID() string {
+	return "ollama/" + o.model
+}
+
+func (o *OllamaEmbedder) ModelVersion() string {
+	if _, tag, ok := strings.Cut(o.model, ":"); ok {
+		return tag
+	}
+	return "latest"
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"anime/internal/repository"
+	"anime/internal/service"
+	"anime/internal/vectorindex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+func (h *AnimeHandler) EmbeddingReportHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := h.repo.EmbeddingModelCounts(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to build embedding report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"activeModel": h.service.EmbeddingModel(), "models": report})
+}
+
+func (h *AnimeHandler) StartReembedHandler(w http.ResponseWriter, r *http.Request) {
+	opts := service.ReembedOptions{
+		DryRun:  r.URL.Query().Get("dryRun") == "true",
+		Force:   r.URL.Query().Get("force") == "true",
+		Restart: r.URL.Query().Get("restart") == "true",
+	}
+
+	if batchSizeStr := r.URL.Query().Get("batchSize"); batchSizeStr != "" {
+		batchSize, err := strconv.Atoi(batchSizeStr)
+		if err != nil || batchSize <= 0 {
+			http.Error(w, "Invalid batchSize parameter", http.StatusBadRequest)
+			return
+		}
+		opts.BatchSize = batchSize
+	}
+
+	status, err := h.service.StartReembed(opts)
+	if errors.Is(err, service.ErrReembedRunning) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(status)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to start re-embed job", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
+
+func (h *AnimeHandler) ReembedStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.ReembedStatus())
+}
+
+// StartSyncHandler starts a full AniList sync, or an incremental one with
+// incremental=true. Optional filters for a full sync are format
+// (comma-separated MediaFormat values), minYear, maxYear and minPopularity.
+func (h *AnimeHandler) StartSyncHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := service.SyncOptions{
+		Formats:     repository.Upper(splitList(q.Get("format"))),
+		Restart:     q.Get("restart") == "true",
+		Incremental: q.Get("incremental") == "true",
+	}
+
+	ints := []struct {
+		name   string
+		target *int
+	}{
+		{"minYear", &opts.MinYear},
+		{"maxYear", &opts.MaxYear},
+		{"minPopularity", &opts.MinPopularity},
+	}
+	for _, param := range ints {
+		valueStr := q.Get(param.name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value < 0 {
+			http.Error(w, "Invalid "+param.name+" parameter", http.StatusBadRequest)
+			return
+		}
+		*param.target = value
+	}
+
+	if perPageStr := q.Get("perPage"); perPageStr != "" {
+		perPage, err := strconv.ParseInt(perPageStr, 10, 64)
+		if err != nil || perPage <= 0 || perPage > 50 {
+			http.Error(w, "Invalid perPage parameter, must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+		opts.PerPage = perPage
+	}
+
+	status, err := h.service.StartSync(opts)
+	if errors.Is(err, service.ErrSyncRunning) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(status)
+		return
+	}
+	if errors.Is(err, service.ErrSyncFilters) {
+		http.Error(w, "Incremental sync does not support filters", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrNoWatermark) {
+		http.Error(w, "No previous full sync, run one without incremental=true first", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Println("start sync error:", err)
+		http.Error(w, "Failed to start sync job", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
+
+func (h *AnimeHandler) SyncStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.SyncStatus())
+}
+
+// AniListBudgetHandler reports the AniList rate limit budget shared by
+// ingestion and sync.
+func (h *AnimeHandler) AniListBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.anilist.Budget())
+}
+
+type recallMeasurer interface {
+	MeasureRecall(queries int, k int, ef int) (vectorindex.RecallReport, error)
+}
+
+func (h *AnimeHandler) IndexRecallHandler(w http.ResponseWriter, r *http.Request) {
+	measurer, ok := h.repo.(recallMeasurer)
+	if !ok {
+		http.Error(w, "In-process vector index is not enabled", http.StatusNotFound)
+		return
+	}
+
+	params := map[string]int{"queries": 100, "k": 10, "ef": 0}
+	for name := range params {
+		valueStr := r.URL.Query().Get(name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value < 0 {
+			http.Error(w, "Invalid "+name+" parameter", http.StatusBadRequest)
+			return
+		}
+		params[name] = value
+	}
+
+	report, err := measurer.MeasureRecall(params["queries"], params["k"], params["ef"])
+	if err != nil {
+		http.Error(w, "Failed to measure recall", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
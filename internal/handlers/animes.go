@@ -1,63 +1,241 @@
 package handlers
 
 import (
-	"anime/internal/database"
 	"anime/internal/embeddings"
 	"anime/internal/models"
+	"anime/internal/repository"
 	"anime/internal/service"
 	"anime/internal/utils"
-	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
-	"sort"
 	"strconv"
 
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/go-chi/chi/v5"
 )
 
-func AnimeByNameHandler(w http.ResponseWriter, r *http.Request) {
+type AnimeHandler struct {
+	repo    repository.AnimeRepository
+	service *service.AnimeService
+	anilist *utils.AniListClient
+}
+
+func NewAnimeHandler(repo repository.AnimeRepository, service *service.AnimeService, anilist *utils.AniListClient) *AnimeHandler {
+	return &AnimeHandler{repo: repo, service: service, anilist: anilist}
+}
+
+func writeRecommendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, embeddings.ErrQuotaExceeded):
+		http.Error(w, "Embedding quota exceeded, try again later", http.StatusTooManyRequests)
+	case errors.Is(err, embeddings.ErrInvalidInput):
+		http.Error(w, "Invalid query for embedding", http.StatusBadRequest)
+	case errors.Is(err, embeddings.ErrProviderUnavailable):
+		http.Error(w, "Embedding provider unavailable", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "Failed to fetch recommendations", http.StatusInternalServerError)
+	}
+}
+
+func (h *AnimeHandler) AnimeByNameHandler(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name == "" {
 		http.Error(w, "Missing name parameter", http.StatusBadRequest)
 		return
 	}
 
-	anime, err := database.GetAnimeByName(name)
+	limit := 5
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, service.MaxNameCandidates)
+	}
+
+	matches, err := h.service.FindByName(r.Context(), name, limit)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Anime not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
+		log.Println("name lookup error:", err)
 		http.Error(w, "Failed to fetch anime", http.StatusInternalServerError)
 		return
 	}
 
+	response := make([]models.NameMatchResponse, 0, len(matches))
+	for _, match := range matches {
+		response = append(response, models.NameMatchResponse{
+			AnimeResponse: utils.ConvertAnimeToResponse(match.Anime),
+			MatchedTitle:  match.MatchedTitle,
+			Confidence:    match.Confidence,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AnimeHandler) SuggestHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "Missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, service.MaxSuggestions)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(anime)
+	json.NewEncoder(w).Encode(h.service.Suggest(q, limit))
 }
 
-func AnimeListHandler(w http.ResponseWriter, r *http.Request) {
-	animes, err := database.GetAnimeList()
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+func (h *AnimeHandler) AnimeListHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseListQuery(r)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeAnimePage(w, r, query)
+}
+
+// writeAnimePage lists the page selected by query, adding facet counts for
+// the query's filter when facets=true.
+func (h *AnimeHandler) writeAnimePage(w http.ResponseWriter, r *http.Request, query repository.ListQuery) {
+	withFacets, err := parseFacets(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	animes, total, err := h.repo.ListPage(r.Context(), query)
+	if err != nil {
+		log.Println("list error:", err)
 		http.Error(w, "Failed to fetch animes", http.StatusInternalServerError)
 		return
 	}
 
+	page := models.AnimePage{Items: animes, Total: total}
+	if next := query.Offset + len(animes); len(animes) > 0 && next < total {
+		page.NextCursor = encodeCursor(next)
+	}
+
+	if withFacets {
+		facets, err := h.repo.Facets(r.Context(), query.Filter)
+		if err != nil {
+			log.Println("facets error:", err)
+			http.Error(w, "Failed to count facets", http.StatusInternalServerError)
+			return
+		}
+		page.Facets = &facets
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(animes)
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseListQuery reads limit, cursor, sort, order and includeEmbedding plus
+// the metadata filters. Numeric sorts default to descending order.
+func parseListQuery(r *http.Request) (repository.ListQuery, error) {
+	q := r.URL.Query()
+	query := repository.ListQuery{Limit: defaultListLimit, Sort: q.Get("sort")}
+
+	if limitStr := q.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return query, errors.New("Invalid limit parameter")
+		}
+		query.Limit = min(limit, maxListLimit)
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			return query, errors.New("Invalid cursor parameter")
+		}
+		query.Offset = offset
+	}
+
+	if !repository.ValidSort(query.Sort) {
+		return query, fmt.Errorf("Invalid sort parameter, must be one of %s, %s, %s or %s",
+			repository.SortScore, repository.SortYear, repository.SortTitle, repository.SortEpisodes)
+	}
+
+	switch q.Get("order") {
+	case "":
+		query.Descending = query.Sort != "" && query.Sort != repository.SortTitle
+	case "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, errors.New("Invalid order parameter, must be asc or desc")
+	}
+
+	if includeStr := q.Get("includeEmbedding"); includeStr != "" {
+		include, err := strconv.ParseBool(includeStr)
+		if err != nil {
+			return query, errors.New("Invalid includeEmbedding parameter")
+		}
+		query.IncludeEmbedding = include
+	}
+
+	filter, err := parseAnimeFilter(r)
+	if err != nil {
+		return query, err
+	}
+	query.Filter = filter
+
+	return query, nil
+}
+
+// Cursors are opaque to clients; they currently carry the offset of the
+// next page.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
 }
 
-func RandomAnimeHandler(w http.ResponseWriter, r *http.Request) {
-	anime, err := database.GetRandomAnime()
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return offset, nil
+}
+
+func (h *AnimeHandler) RandomAnimeHandler(w http.ResponseWriter, r *http.Request) {
+	anime, err := h.repo.Random(r.Context())
 	if err != nil {
 		http.Error(w, "Failed to fetch anime", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(anime)
+	json.NewEncoder(w).Encode(utils.ConvertAnimeToResponse(anime))
 }
 
-func TopRatedAnimesHandler(w http.ResponseWriter, r *http.Request) {
+func (h *AnimeHandler) TopRatedAnimesHandler(w http.ResponseWriter, r *http.Request) {
 	limitStr := r.URL.Query().Get("limit")
 	if limitStr == "" {
 		http.Error(w, "Missing limit parameter", http.StatusBadRequest)
@@ -70,190 +248,211 @@ func TopRatedAnimesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	animes, err := database.GetTopRatedAnimes(limit)
+	animes, err := h.repo.TopRated(r.Context(), limit)
 	if err != nil {
 		http.Error(w, "Failed to fetch animes", http.StatusInternalServerError)
 		return
 	}
 
+	response := make([]models.AnimeResponse, 0, len(animes))
+	for _, anime := range animes {
+		response = append(response, utils.ConvertAnimeToResponse(anime))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(animes)
+	json.NewEncoder(w).Encode(response)
 }
 
-func RecommendHandler(w http.ResponseWriter, r *http.Request) {
+func (h *AnimeHandler) RecommendHandler(w http.ResponseWriter, r *http.Request) {
+	h.recommend(w, r, 1)
+}
+
+func (h *AnimeHandler) NewRecommendHandler(w http.ResponseWriter, r *http.Request) {
+	h.recommend(w, r, 2)
+}
+
+func (h *AnimeHandler) recommend(w http.ResponseWriter, r *http.Request, defaultLimit int) {
 	query := r.URL.Query().Get("query")
 	if query == "" {
 		http.Error(w, "Missing query parameter", http.StatusBadRequest)
 		return
 	}
 
-	queryEmbedding, err := embeddings.GenerateEmbedding(query)
+	opts, err := parseRecommendOptions(r, defaultLimit)
 	if err != nil {
-		http.Error(w, "Failed to generate embedding", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	animes, err := database.GetAnimeList()
+	results, err := h.service.Recommend(r.Context(), query, opts)
 	if err != nil {
-		http.Error(w, "Failed to fetch anime list", http.StatusInternalServerError)
+		log.Println("recommend error:", err)
+		writeRecommendError(w, err)
 		return
 	}
 
-	type ScoredAnime struct {
-		Anime models.Anime
-		Score float64
+	if len(results) == 0 {
+		log.Println("No results found")
 	}
-	var results []ScoredAnime
-
-	for _, anime := range animes {
-		if len(anime.Embedding) != len(queryEmbedding) {
-			continue
-		}
 
-		a := make([]float32, len(queryEmbedding))
-		b := make([]float32, len(queryEmbedding))
-		for i := range queryEmbedding {
-			a[i] = float32(queryEmbedding[i])
-			b[i] = float32(anime.Embedding[i])
-		}
-
-		similarity, err := utils.CosineSimilarity(a, b)
-		if err != nil {
-			log.Println("Cosine error:", err)
-			continue
-		}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toReccResponses(results))
+}
 
-		results = append(results, ScoredAnime{Anime: anime, Score: similarity})
+func (h *AnimeHandler) SimilarAnimeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid anime id", http.StatusBadRequest)
+		return
 	}
 
-	if len(results) == 0 {
-		log.Println("No results found")
+	opts, err := parseRecommendOptions(r, 10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
 
-	top := min(len(results), 1)
-	topAnimes := make([]models.AnimeResponse, top)
-
-	for i := range top {
-		a := results[i].Anime
-		topAnimes[i] = models.AnimeResponse{
-			ID:           a.ID,
-			Title:        a.Title,
-			Description:  a.Description,
-			Genres:       a.Genres,
-			AverageScore: a.AverageScore,
-			Episodes:     a.Episodes,
-			Duration:     a.Duration,
-			Season:       a.Season,
-			SeasonYear:   a.SeasonYear,
-			Status:       a.Status,
-			Source:       a.Source,
-			Studios:      a.Studios,
-			CoverImage:   a.CoverImage,
-		}
+	results, err := h.service.Similar(r.Context(), id, opts)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Anime not found", http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrSeedNotEmbedded):
+		http.Error(w, "Anime has no embedding for the active model", http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		log.Println("similar error:", err)
+		http.Error(w, "Failed to fetch recommendations", http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(topAnimes)
+	json.NewEncoder(w).Encode(toReccResponses(results))
 }
 
-func NewRecommendHandler(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("query")
-	if query == "" {
-		http.Error(w, "Missing query parameter", http.StatusBadRequest)
-		return
+func parseRecommendOptions(r *http.Request, defaultLimit int) (service.RecommendOptions, error) {
+	opts := service.RecommendOptions{Limit: defaultLimit}
+	q := r.URL.Query()
+
+	if limitStr := q.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return opts, errors.New("Invalid limit parameter")
+		}
+		opts.Limit = min(limit, service.MaxRecommendLimit)
 	}
 
-	queryEmbedding, err := embeddings.GenerateEmbeddingsOllama(query)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Failed to generate embedding", http.StatusInternalServerError)
-		return
-	}
-
-	vectorSearchStage := bson.D{
-		{Key: "$vectorSearch", Value: bson.D{
-			{Key: "index", Value: "new_embeddings_vector_index"},
-			{Key: "path", Value: "embedding"},
-			{Key: "queryVector", Value: queryEmbedding},
-			{Key: "numCandidates", Value: 100},
-			{Key: "limit", Value: 2},
-		}}}
-
-	projectStage := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 0},
-			{Key: "id", Value: 1},
-			{Key: "title", Value: 1},
-			{Key: "description", Value: 1},
-			{Key: "genres", Value: 1},
-			{Key: "averageScore", Value: 1},
-			{Key: "episodes", Value: 1},
-			{Key: "duration", Value: 1},
-			{Key: "season", Value: 1},
-			{Key: "seasonYear", Value: 1},
-			{Key: "status", Value: 1},
-			{Key: "source", Value: 1},
-			{Key: "studios", Value: 1},
-			{Key: "coverImage", Value: 1},
-			{Key: "score", Value: bson.D{{Key: "$meta", Value: "vectorSearchScore"}}},
-		}}}
-
-	cursor, err := database.NewAnimeCollection.Aggregate(context.Background(), mongo.Pipeline{vectorSearchStage, projectStage})
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Failed to fetch anime", http.StatusInternalServerError)
-		return
+	if offsetStr := q.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 || offset > service.MaxRecommendOffset {
+			return opts, fmt.Errorf("Invalid offset parameter, must be between 0 and %d", service.MaxRecommendOffset)
+		}
+		opts.Offset = offset
 	}
-	defer cursor.Close(context.Background())
 
-	var animes []models.AnimeReccResponse
-	for cursor.Next(context.Background()) {
-		var anime models.AnimeReccResponse
-		if err := cursor.Decode(&anime); err != nil {
-			log.Println("decode error:", err)
+	if minScoreStr := q.Get("minScore"); minScoreStr != "" {
+		minScore, err := strconv.ParseFloat(minScoreStr, 64)
+		if err != nil || minScore < -1 || minScore > 1 {
+			return opts, fmt.Errorf("Invalid minScore parameter, must be between -1 and 1")
+		}
+		opts.MinScore = minScore
+	} else {
+		opts.MinScore = math.Inf(-1)
+	}
+
+	if diversityStr := q.Get("diversity"); diversityStr != "" {
+		diversity, err := strconv.ParseFloat(diversityStr, 64)
+		if err != nil || diversity < 0 || diversity > 1 {
+			return opts, errors.New("Invalid diversity parameter, must be between 0 and 1")
+		}
+		opts.Diversity = diversity
+	}
+
+	caps := []struct {
+		name   string
+		target *int
+	}{
+		{"maxPerGenre", &opts.MaxPerGenre},
+		{"maxPerStudio", &opts.MaxPerStudio},
+	}
+	for _, param := range caps {
+		valueStr := q.Get(param.name)
+		if valueStr == "" {
 			continue
 		}
-		animes = append(animes, anime)
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value < 0 {
+			return opts, fmt.Errorf("Invalid %s parameter", param.name)
+		}
+		*param.target = value
 	}
 
-	if len(animes) == 0 {
-		log.Println("No results found")
+	if explainStr := q.Get("explain"); explainStr != "" {
+		explain, err := strconv.ParseBool(explainStr)
+		if err != nil {
+			return opts, errors.New("Invalid explain parameter")
+		}
+		opts.Explain = explain
 	}
-	sort.Slice(animes, func(i, j int) bool {
-		return animes[i].Score > animes[j].Score
-	})
 
-	top := min(len(animes), 2)
-	topAnimes := make([]models.AnimeResponse, top)
-
-	for i := range top {
-		a := animes[i]
-		topAnimes[i] = models.AnimeResponse{
-			ID:           a.ID,
-			Title:        a.Title,
-			Description:  a.Description,
-			Genres:       a.Genres,
-			AverageScore: a.AverageScore,
-			Episodes:     a.Episodes,
-			Duration:     a.Duration,
-			Season:       a.Season,
-			SeasonYear:   a.SeasonYear,
-			Status:       a.Status,
-			Source:       a.Source,
-			Studios:      a.Studios,
-			CoverImage:   a.CoverImage,
+	weights := []struct {
+		name   string
+		target **float64
+	}{
+		{"weightSimilarity", &opts.Weights.Similarity},
+		{"weightQuality", &opts.Weights.Quality},
+		{"weightPopularity", &opts.Weights.Popularity},
+		{"weightRecency", &opts.Weights.Recency},
+	}
+	for _, param := range weights {
+		valueStr := q.Get(param.name)
+		if valueStr == "" {
+			continue
 		}
+		value, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil || value < 0 {
+			return opts, fmt.Errorf("Invalid %s parameter, must not be negative", param.name)
+		}
+		*param.target = &value
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(topAnimes)
+	filter, err := parseAnimeFilter(r)
+	if err != nil {
+		return opts, err
+	}
+	opts.Filter = filter
 
+	return opts, nil
 }
 
-func GraphQLAPIHandler(w http.ResponseWriter, r *http.Request) {
+// rankingParams are the parseRecommendOptions parameters that cut off,
+// re-rank or weight results by similarity.
+var rankingParams = []string{
+	"minScore", "diversity", "maxPerGenre", "maxPerStudio",
+	"weightSimilarity", "weightQuality", "weightPopularity", "weightRecency",
+}
+
+// parseSearchOptions parses the recommendation options search supports.
+// Search orders by fused keyword and vector ranks rather than similarity,
+// so it rejects rankingParams instead of ignoring them.
+func parseSearchOptions(r *http.Request, defaultLimit int) (service.RecommendOptions, error) {
+	for _, name := range rankingParams {
+		if r.URL.Query().Has(name) {
+			return service.RecommendOptions{}, fmt.Errorf("The %s parameter is not supported by search", name)
+		}
+	}
+	return parseRecommendOptions(r, defaultLimit)
+}
+
+func toReccResponses(results []models.ScoredAnime) []models.AnimeReccResponse {
+	response := make([]models.AnimeReccResponse, 0, len(results))
+	for _, result := range results {
+		response = append(response, utils.ConvertScoredToRecc(result))
+	}
+	return response
+}
+
+func (h *AnimeHandler) GraphQLAPIHandler(w http.ResponseWriter, r *http.Request) {
 	perPageStr := r.URL.Query().Get("perPage")
 	if perPageStr == "" {
 		http.Error(w, "Missing perPage parameter", http.StatusBadRequest)
@@ -278,7 +477,7 @@ func GraphQLAPIHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	animes, err := utils.GraphQLAPIRequest(page, perPage)
+	animes, err := h.anilist.GraphQLAPIRequest(r.Context(), page, perPage)
 	if err != nil {
 		http.Error(w, "Failed to fetch animes", http.StatusInternalServerError)
 		return
@@ -296,7 +495,7 @@ func GraphQLAPIHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(animesTitle)
 }
 
-func InsertAnimeHandler(w http.ResponseWriter, r *http.Request) {
+func (h *AnimeHandler) InsertAnimeHandler(w http.ResponseWriter, r *http.Request) {
 	perPageStr := r.URL.Query().Get("perPage")
 	if perPageStr == "" {
 		http.Error(w, "Missing perPage parameter", http.StatusBadRequest)
@@ -321,18 +520,17 @@ func InsertAnimeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	count, err := service.InsertAnimes(page, perPage)
+	report, err := h.service.InsertAnimes(r.Context(), page, perPage)
 	if err != nil {
+		log.Println("insert error:", err)
 		http.Error(w, "Failed to insert animes", http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]any{"status": "success", "message": "Animes inserted successfully", "count": count})
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	writeIngestReport(w, report)
 }
 
-func InsertAnimeConcurrentHandler(w http.ResponseWriter, r *http.Request) {
+func (h *AnimeHandler) InsertAnimeConcurrentHandler(w http.ResponseWriter, r *http.Request) {
 	perPageStr := r.URL.Query().Get("perPage")
 	if perPageStr == "" {
 		http.Error(w, "Missing perPage parameter", http.StatusBadRequest)
@@ -369,13 +567,57 @@ func InsertAnimeConcurrentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	count, err := service.InsertAnimesConcurrent(startPage, endPage, perPage)
+	var opts service.PipelineOptions
+	ints := []struct {
+		name   string
+		target *int
+	}{
+		{"fetchConcurrency", &opts.FetchConcurrency},
+		{"embedConcurrency", &opts.EmbedConcurrency},
+		{"batchSize", &opts.InsertBatchSize},
+	}
+	for _, param := range ints {
+		valueStr := r.URL.Query().Get(param.name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid %s parameter", param.name), http.StatusBadRequest)
+			return
+		}
+		*param.target = value
+	}
+
+	report, err := h.service.InsertAnimesConcurrent(r.Context(), startPage, endPage, perPage, opts)
 	if err != nil {
+		log.Println("insert error:", err)
 		http.Error(w, "Failed to insert animes", http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]any{"status": "success", "message": "Animes inserted successfully", "count": count})
+	writeIngestReport(w, report)
+}
+
+func writeIngestReport(w http.ResponseWriter, report models.IngestReport) {
+	status, message := "success", "Animes inserted successfully"
+	failedPages := report.FailedPages
+	if len(failedPages) > 0 {
+		status, message = "partial", "Some pages failed to ingest"
+	} else {
+		failedPages = []int64{}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":      status,
+		"message":     message,
+		"count":       report.Stored(),
+		"inserted":    report.Inserted,
+		"updated":     report.Updated,
+		"unchanged":   report.Unchanged,
+		"failed":      report.Failed,
+		"embedded":    report.Embedded,
+		"failedPages": failedPages,
+	})
 }
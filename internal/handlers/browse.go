@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"anime/internal/models"
+	"anime/internal/repository"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var seasons = []string{"WINTER", "SPRING", "SUMMER", "FALL"}
+
+func (h *AnimeHandler) GenresHandler(w http.ResponseWriter, r *http.Request) {
+	facets, err := h.repo.Facets(r.Context(), models.AnimeFilter{})
+	if err != nil {
+		log.Println("genres error:", err)
+		http.Error(w, "Failed to fetch genres", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(facets.Genres)
+}
+
+// GenreAnimeHandler lists the animes of one genre with the same paging,
+// sorting and filter parameters as the anime list.
+func (h *AnimeHandler) GenreAnimeHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseListQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	genre, err := url.PathUnescape(chi.URLParam(r, "genre"))
+	if err != nil {
+		http.Error(w, "Invalid genre", http.StatusBadRequest)
+		return
+	}
+	query.Filter.Genres = append(query.Filter.Genres, genre)
+
+	h.writeAnimePage(w, r, query)
+}
+
+func (h *AnimeHandler) StudiosHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.repo.StudioStats(r.Context())
+	if err != nil {
+		log.Println("studios error:", err)
+		http.Error(w, "Failed to fetch studios", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *AnimeHandler) StudioAnimeHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseListQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name, err := url.PathUnescape(chi.URLParam(r, "name"))
+	if err != nil {
+		http.Error(w, "Invalid studio name", http.StatusBadRequest)
+		return
+	}
+	query.Filter.Studios = []string{name}
+
+	h.writeAnimePage(w, r, query)
+}
+
+// SeasonAnimeHandler lists one season's lineup, best rated first unless
+// another sort is requested.
+func (h *AnimeHandler) SeasonAnimeHandler(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if err != nil || year <= 0 {
+		http.Error(w, "Invalid year", http.StatusBadRequest)
+		return
+	}
+	season := strings.ToUpper(chi.URLParam(r, "season"))
+	if !slices.Contains(seasons, season) {
+		http.Error(w, "Invalid season, must be one of winter, spring, summer or fall", http.StatusBadRequest)
+		return
+	}
+
+	query, err := parseListQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("sort") == "" {
+		query.Sort = repository.SortScore
+		query.Descending = r.URL.Query().Get("order") != "asc"
+	}
+	query.Filter.MinYear, query.Filter.MaxYear = year, year
+	query.Filter.Seasons = []string{season}
+
+	h.writeAnimePage(w, r, query)
+}
+
+func parseFacets(r *http.Request) (bool, error) {
+	facetsStr := r.URL.Query().Get("facets")
+	if facetsStr == "" {
+		return false, nil
+	}
+	facets, err := strconv.ParseBool(facetsStr)
+	if err != nil {
+		return false, errors.New("Invalid facets parameter")
+	}
+	return facets, nil
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"anime/internal/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseAnimeFilter reads metadata filters from the query string. List
+// values are comma-separated, e.g. genres=Action,Drama&status=FINISHED.
+func parseAnimeFilter(r *http.Request) (models.AnimeFilter, error) {
+	q := r.URL.Query()
+	f := models.AnimeFilter{
+		Genres:        splitList(q.Get("genres")),
+		ExcludeGenres: splitList(q.Get("excludeGenres")),
+		Seasons:       splitList(q.Get("season")),
+		Statuses:      splitList(q.Get("status")),
+		Sources:       splitList(q.Get("source")),
+		Studios:       splitList(q.Get("studio")),
+	}
+
+	ints := []struct {
+		name   string
+		target *int
+	}{
+		{"minYear", &f.MinYear},
+		{"maxYear", &f.MaxYear},
+		{"minAverageScore", &f.MinAverageScore},
+		{"maxAverageScore", &f.MaxAverageScore},
+		{"minEpisodes", &f.MinEpisodes},
+		{"maxEpisodes", &f.MaxEpisodes},
+		{"minDuration", &f.MinDuration},
+		{"maxDuration", &f.MaxDuration},
+	}
+	for _, param := range ints {
+		valueStr := q.Get(param.name)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value < 0 {
+			return f, fmt.Errorf("Invalid %s parameter", param.name)
+		}
+		*param.target = value
+	}
+
+	if yearStr := q.Get("seasonYear"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil || year <= 0 {
+			return f, fmt.Errorf("Invalid seasonYear parameter")
+		}
+		f.MinYear, f.MaxYear = year, year
+	}
+
+	return f, nil
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
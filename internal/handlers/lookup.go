@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"anime/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const maxBatchIDs = 100
+
+func (h *AnimeHandler) AnimeByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid anime id", http.StatusBadRequest)
+		return
+	}
+
+	includeEmbedding := false
+	if includeStr := r.URL.Query().Get("includeEmbedding"); includeStr != "" {
+		includeEmbedding, err = strconv.ParseBool(includeStr)
+		if err != nil {
+			http.Error(w, "Invalid includeEmbedding parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	animes, err := h.repo.GetByIDs(r.Context(), []int{id}, includeEmbedding)
+	if err != nil {
+		log.Println("get anime error:", err)
+		http.Error(w, "Failed to fetch anime", http.StatusInternalServerError)
+		return
+	}
+	if len(animes) == 0 {
+		http.Error(w, "Anime not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(animes[0])
+}
+
+// BatchAnimeHandler looks up several IDs at once. Items follow the request
+// order, including duplicates, and IDs that do not exist are marked with
+// found=false.
+func (h *AnimeHandler) BatchAnimeHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 || len(req.IDs) > maxBatchIDs {
+		http.Error(w, fmt.Sprintf("Invalid ids, must contain between 1 and %d IDs", maxBatchIDs), http.StatusBadRequest)
+		return
+	}
+
+	animes, err := h.repo.GetByIDs(r.Context(), req.IDs, req.IncludeEmbedding)
+	if err != nil {
+		log.Println("batch lookup error:", err)
+		http.Error(w, "Failed to fetch animes", http.StatusInternalServerError)
+		return
+	}
+
+	byID := make(map[int]*models.Anime, len(animes))
+	for i := range animes {
+		byID[animes[i].ID] = &animes[i]
+	}
+
+	response := models.BatchResponse{Items: make([]models.BatchItem, 0, len(req.IDs))}
+	for _, id := range req.IDs {
+		anime, ok := byID[id]
+		response.Items = append(response.Items, models.BatchItem{ID: id, Found: ok, Anime: anime})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"anime/internal/models"
+	"anime/internal/repository"
+	"anime/internal/service"
+	"anime/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+)
+
+func (h *AnimeHandler) RecommendFromSeedsHandler(w http.ResponseWriter, r *http.Request) {
+	// Unknown fields are rejected so that query-string style parameters
+	// such as weightQuality are not silently ignored in the body.
+	var req models.RecommendRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := recommendOptionsFromRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.RecommendFromSeeds(r.Context(), req, opts)
+	switch {
+	case errors.Is(err, service.ErrInvalidSeeds):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrSeedNotEmbedded):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		log.Println("recommend error:", err)
+		writeRecommendError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toReccResponses(results))
+}
+
+func recommendOptionsFromRequest(req models.RecommendRequest) (service.RecommendOptions, error) {
+	opts := service.RecommendOptions{
+		Limit:        10,
+		Offset:       req.Offset,
+		MinScore:     math.Inf(-1),
+		Filter:       req.Filter,
+		Diversity:    req.Diversity,
+		MaxPerGenre:  req.MaxPerGenre,
+		MaxPerStudio: req.MaxPerStudio,
+		Weights:      req.Weights,
+		Explain:      req.Explain,
+	}
+
+	if req.Limit < 0 {
+		return opts, errors.New("Invalid limit, must be positive")
+	}
+	if req.Limit > 0 {
+		opts.Limit = min(req.Limit, service.MaxRecommendLimit)
+	}
+
+	if req.Offset < 0 || req.Offset > service.MaxRecommendOffset {
+		return opts, fmt.Errorf("Invalid offset, must be between 0 and %d", service.MaxRecommendOffset)
+	}
+
+	if req.MinScore != nil {
+		if *req.MinScore < -1 || *req.MinScore > 1 {
+			return opts, errors.New("Invalid minScore, must be between -1 and 1")
+		}
+		opts.MinScore = *req.MinScore
+	}
+
+	if req.Diversity < 0 || req.Diversity > 1 {
+		return opts, errors.New("Invalid diversity, must be between 0 and 1")
+	}
+	if req.MaxPerGenre < 0 || req.MaxPerStudio < 0 {
+		return opts, errors.New("Invalid maxPerGenre or maxPerStudio, must not be negative")
+	}
+
+	for _, weight := range []*float64{req.Weights.Similarity, req.Weights.Quality, req.Weights.Popularity, req.Weights.Recency} {
+		if weight != nil && *weight < 0 {
+			return opts, errors.New("Invalid weights, must not be negative")
+		}
+	}
+	return opts, nil
+}
+
+func (h *AnimeHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseSearchOptions(r, 10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	withFacets, err := parseFacets(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.Search(r.Context(), query, opts)
+	if err != nil {
+		log.Println("search error:", err)
+		writeRecommendError(w, err)
+		return
+	}
+
+	response := make([]models.SearchResponse, 0, len(results))
+	for _, result := range results {
+		response = append(response, models.SearchResponse{
+			AnimeResponse: utils.ConvertAnimeToResponse(result.Anime),
+			Score:         result.Score,
+			Lexical:       result.Lexical,
+			Semantic:      result.Semantic,
+			Explanation:   result.Explanation,
+		})
+	}
+
+	if !withFacets {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	facets, err := h.repo.Facets(r.Context(), opts.Filter)
+	if err != nil {
+		log.Println("facets error:", err)
+		http.Error(w, "Failed to count facets", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SearchPage{Items: response, Facets: &facets})
+}
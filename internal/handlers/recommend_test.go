@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"anime/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecommendOptionsFromRequest(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		req     models.RecommendRequest
+		wantErr bool
+	}{
+		{"defaults", models.RecommendRequest{}, false},
+		{"min score in range", models.RecommendRequest{MinScore: score(0.5)}, false},
+		{"min score lowest", models.RecommendRequest{MinScore: score(-1)}, false},
+		{"min score too high", models.RecommendRequest{MinScore: score(1.5)}, true},
+		{"min score too low", models.RecommendRequest{MinScore: score(-2)}, true},
+		{"negative limit", models.RecommendRequest{Limit: -1}, true},
+		{"diversity too high", models.RecommendRequest{Diversity: 2}, true},
+		{"negative weight", models.RecommendRequest{Weights: models.RankingWeights{Quality: score(-1)}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := recommendOptionsFromRequest(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRankingParamsParsed(t *testing.T) {
+	defaults, err := parseRecommendOptions(httptest.NewRequest(http.MethodGet, "/", nil), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range rankingParams {
+		t.Run(name, func(t *testing.T) {
+			opts, err := parseRecommendOptions(httptest.NewRequest(http.MethodGet, "/?"+name+"=1", nil), 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reflect.DeepEqual(opts, defaults) {
+				t.Errorf("parseRecommendOptions ignores %s", name)
+			}
+		})
+	}
+}
+
+func TestRejectedRankingParameters(t *testing.T) {
+	h := NewAnimeHandler(nil, nil, nil)
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		handler  http.HandlerFunc
+		wantBody string
+	}{
+		{"search min score", http.MethodGet, "/search?q=x&minScore=0.5", "", h.SearchHandler, "minScore"},
+		{"search weight", http.MethodGet, "/search?q=x&weightQuality=1", "", h.SearchHandler, "weightQuality"},
+		{"search diversity", http.MethodGet, "/search?q=x&diversity=0", "", h.SearchHandler, "diversity"},
+		{"recommend min score out of range", http.MethodPost, "/recommend", `{"likes":[1],"minScore":2}`, h.RecommendFromSeedsHandler, "minScore"},
+		{"recommend query-string weight", http.MethodPost, "/recommend", `{"likes":[1],"weightQuality":1}`, h.RecommendFromSeedsHandler, "weightQuality"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("got %d %q, want 400 mentioning %s", rec.Code, rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type Title struct {
 	Romaji         string `bson:"romaji,omitempty" json:"romaji,omitempty"`
 	English        string `bson:"english,omitempty" json:"english,omitempty"`
@@ -12,20 +17,38 @@ type CoverImage struct {
 }
 
 type Anime struct {
-	ID           int        `bson:"id,omitempty" json:"id,omitempty"`
-	Title        Title      `bson:"title,omitempty" json:"title"`
-	Description  string     `bson:"description,omitempty" json:"description,omitempty"`
-	Genres       []string   `bson:"genres,omitempty" json:"genres,omitempty"`
-	AverageScore int        `bson:"averageScore,omitempty" json:"averageScore,omitempty"`
-	Episodes     int        `bson:"episodes,omitempty" json:"episodes,omitempty"`
-	Duration     int        `bson:"duration,omitempty" json:"duration,omitempty"`
-	Season       string     `bson:"season,omitempty" json:"season,omitempty"`
-	SeasonYear   int        `bson:"seasonYear,omitempty" json:"seasonYear,omitempty"`
-	Status       string     `bson:"status,omitempty" json:"status,omitempty"`
-	Source       string     `bson:"source,omitempty" json:"source,omitempty"`
-	Studios      []string   `bson:"studios,omitempty" json:"studios,omitempty"`
-	CoverImage   CoverImage `bson:"coverImage,omitempty" json:"coverImage,omitempty"`
-	Embedding    []float32  `bson:"embedding,omitempty" json:"embedding,omitempty"`
+	ID            int            `bson:"id,omitempty" json:"id,omitempty"`
+	Title         Title          `bson:"title,omitempty" json:"title"`
+	Description   string         `bson:"description,omitempty" json:"description,omitempty"`
+	Genres        []string       `bson:"genres,omitempty" json:"genres,omitempty"`
+	AverageScore  int            `bson:"averageScore,omitempty" json:"averageScore,omitempty"`
+	Popularity    int            `bson:"popularity,omitempty" json:"popularity,omitempty"`
+	Episodes      int            `bson:"episodes,omitempty" json:"episodes,omitempty"`
+	Duration      int            `bson:"duration,omitempty" json:"duration,omitempty"`
+	Season        string         `bson:"season,omitempty" json:"season,omitempty"`
+	SeasonYear    int            `bson:"seasonYear,omitempty" json:"seasonYear,omitempty"`
+	Status        string         `bson:"status,omitempty" json:"status,omitempty"`
+	Source        string         `bson:"source,omitempty" json:"source,omitempty"`
+	Studios       []string       `bson:"studios,omitempty" json:"studios,omitempty"`
+	CoverImage    CoverImage     `bson:"coverImage,omitempty" json:"coverImage,omitempty"`
+	Embedding     []float32      `bson:"embedding,omitempty" json:"embedding,omitempty"`
+	EmbeddingInfo *EmbeddingInfo `bson:"embeddingInfo,omitempty" json:"embeddingInfo,omitempty"`
+	// ContentHash fingerprints the text the embedding was computed from, so
+	// ingestion can skip re-embedding unchanged animes.
+	ContentHash string `bson:"contentHash,omitempty" json:"contentHash,omitempty"`
+}
+
+type EmbeddingInfo struct {
+	Model      string    `bson:"model" json:"model"`
+	Version    string    `bson:"version,omitempty" json:"version,omitempty"`
+	Dimensions int       `bson:"dimensions" json:"dimensions"`
+	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+type EmbeddingModelCount struct {
+	Model      string `bson:"model" json:"model"`
+	Dimensions int    `bson:"dimensions" json:"dimensions"`
+	Count      int    `bson:"count" json:"count"`
 }
 
 type AnimeResponse struct {
@@ -34,6 +57,7 @@ type AnimeResponse struct {
 	Description  string     `json:"description"`
 	Genres       []string   `json:"genres"`
 	AverageScore int        `json:"averageScore"`
+	Popularity   int        `json:"popularity"`
 	Episodes     int        `json:"episodes"`
 	Duration     int        `json:"duration"`
 	Season       string     `json:"season"`
@@ -42,6 +66,9 @@ type AnimeResponse struct {
 	Source       string     `json:"source"`
 	Studios      []string   `json:"studios"`
 	CoverImage   CoverImage `json:"coverImage"`
+	// UpdatedAt is when AniList last changed the entry, in Unix seconds.
+	// It is only used by incremental sync and not stored.
+	UpdatedAt int64 `json:"updatedAt,omitempty"`
 }
 
 type AnimeTitleResponse struct {
@@ -50,25 +77,29 @@ type AnimeTitleResponse struct {
 }
 
 type AnimeReccResponse struct {
-	ID           int        `bson:"id,omitempty" json:"id"`
-	Title        Title      `bson:"title,omitempty" json:"title"`
-	Description  string     `bson:"description,omitempty" json:"description"`
-	Genres       []string   `bson:"genres,omitempty" json:"genres"`
-	AverageScore int        `bson:"averageScore,omitempty" json:"averageScore"`
-	Episodes     int        `bson:"episodes,omitempty" json:"episodes"`
-	Duration     int        `bson:"duration,omitempty" json:"duration"`
-	Season       string     `bson:"season,omitempty" json:"season"`
-	SeasonYear   int        `bson:"seasonYear,omitempty" json:"seasonYear"`
-	Status       string     `bson:"status,omitempty" json:"status"`
-	Source       string     `bson:"source,omitempty" json:"source"`
-	Studios      []string   `bson:"studios,omitempty" json:"studios"`
-	CoverImage   CoverImage `bson:"coverImage,omitempty" json:"coverImage"`
-	Score        float64    `bson:"score,omitempty" json:"score"`
+	ID           int              `bson:"id,omitempty" json:"id"`
+	Title        Title            `bson:"title,omitempty" json:"title"`
+	Description  string           `bson:"description,omitempty" json:"description"`
+	Genres       []string         `bson:"genres,omitempty" json:"genres"`
+	AverageScore int              `bson:"averageScore,omitempty" json:"averageScore"`
+	Popularity   int              `bson:"popularity,omitempty" json:"popularity"`
+	Episodes     int              `bson:"episodes,omitempty" json:"episodes"`
+	Duration     int              `bson:"duration,omitempty" json:"duration"`
+	Season       string           `bson:"season,omitempty" json:"season"`
+	SeasonYear   int              `bson:"seasonYear,omitempty" json:"seasonYear"`
+	Status       string           `bson:"status,omitempty" json:"status"`
+	Source       string           `bson:"source,omitempty" json:"source"`
+	Studios      []string         `bson:"studios,omitempty" json:"studios"`
+	CoverImage   CoverImage       `bson:"coverImage,omitempty" json:"coverImage"`
+	Score        float64          `bson:"score,omitempty" json:"score"`
+	Components   *ScoreComponents `bson:"-" json:"components,omitempty"`
+	Explanation  *Explanation     `bson:"-" json:"explanation,omitempty"`
 }
 
 type AnimeAPIResponse struct {
 	Page struct {
-		Media []struct {
+		PageInfo PageInfo `json:"pageInfo"`
+		Media    []struct {
 			ID    int `json:"id"`
 			Title struct {
 				Romaji  string `json:"romaji"`
@@ -77,6 +108,8 @@ type AnimeAPIResponse struct {
 			Description  string   `json:"description"`
 			Genres       []string `json:"genres"`
 			AverageScore int      `json:"averageScore"`
+			Popularity   int      `json:"popularity"`
+			UpdatedAt    int64    `json:"updatedAt"`
 			Episodes     int      `json:"episodes"`
 			Duration     int      `json:"duration"`
 			Season       string   `json:"season"`
@@ -95,11 +128,35 @@ type AnimeAPIResponse struct {
 	} `json:"Page"`
 }
 
+type PageInfo struct {
+	Total       int  `json:"total"`
+	CurrentPage int  `json:"currentPage"`
+	LastPage    int  `json:"lastPage"`
+	HasNextPage bool `json:"hasNextPage"`
+	PerPage     int  `json:"perPage"`
+}
+
+// AniListQuery selects one page of AniList anime. Zero filters are not
+// applied; Sort defaults to POPULARITY_DESC.
+type AniListQuery struct {
+	Page          int64    `json:"page"`
+	PerPage       int64    `json:"perPage"`
+	Sort          string   `json:"sort,omitempty"`
+	Formats       []string `json:"formats,omitempty"`
+	MinYear       int      `json:"minYear,omitempty"`
+	MaxYear       int      `json:"maxYear,omitempty"`
+	MinPopularity int      `json:"minPopularity,omitempty"`
+}
+
 type GraphQLRequest struct {
 	Query     string                 `json:"query"`
 	Variables map[string]interface{} `json:"variables"`
 }
 
+type GraphQLError struct {
+	Message string `json:"message"`
+}
+
 type OllamaRequest struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
@@ -108,3 +165,264 @@ type OllamaRequest struct {
 type OllamaResponse struct {
 	Embeddings []float32 `json:"embedding"`
 }
+
+type ScoredAnime struct {
+	Anime       Anime
+	Score       float64
+	Components  *ScoreComponents
+	Explanation *Explanation
+}
+
+// Explanation describes why an anime was recommended.
+type Explanation struct {
+	SharedGenres    []string         `json:"sharedGenres"`
+	SharedStudios   []string         `json:"sharedStudios"`
+	MostSimilarSeed *SeedSimilarity  `json:"mostSimilarSeed,omitempty"`
+	MatchedKeywords []string         `json:"matchedKeywords,omitempty"`
+	Components      *ScoreComponents `json:"components,omitempty"`
+}
+
+type SeedSimilarity struct {
+	ID         int     `json:"id"`
+	Title      Title   `json:"title"`
+	Similarity float64 `json:"similarity"`
+}
+
+type AnimePage struct {
+	Items      []Anime `json:"items"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+	Facets     *Facets `json:"facets,omitempty"`
+}
+
+type FacetCount struct {
+	Value string `bson:"_id" json:"value"`
+	Count int    `bson:"count" json:"count"`
+}
+
+// Facets counts the animes matching a filter per value of each field.
+// Counts within a facet are sorted by descending count.
+type Facets struct {
+	Genres   []FacetCount `bson:"genres" json:"genres"`
+	Statuses []FacetCount `bson:"status" json:"status"`
+	Seasons  []FacetCount `bson:"season" json:"season"`
+	Sources  []FacetCount `bson:"source" json:"source"`
+	Studios  []FacetCount `bson:"studios" json:"studios"`
+}
+
+type StudioStats struct {
+	Name  string `bson:"_id" json:"name"`
+	Count int    `bson:"count" json:"count"`
+	// AverageScore is the mean averageScore of the studio's scored animes,
+	// or 0 when none has a score.
+	AverageScore float64 `bson:"averageScore" json:"averageScore"`
+}
+
+type BatchRequest struct {
+	IDs              []int `json:"ids"`
+	IncludeEmbedding bool  `json:"includeEmbedding"`
+}
+
+// BatchItem is one requested ID; Anime is nil when Found is false.
+type BatchItem struct {
+	ID    int    `json:"id"`
+	Found bool   `json:"found"`
+	Anime *Anime `json:"anime,omitempty"`
+}
+
+type BatchResponse struct {
+	Items []BatchItem `json:"items"`
+}
+
+type NameMatch struct {
+	Anime        Anime
+	MatchedTitle string
+	Confidence   float64
+}
+
+type NameMatchResponse struct {
+	AnimeResponse
+	MatchedTitle string  `json:"matchedTitle"`
+	Confidence   float64 `json:"confidence"`
+}
+
+type TitleSuggestion struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	Popularity int    `json:"popularity"`
+}
+
+// ScoreComponents are the normalized signals a recommendation score was
+// blended from, each in [0, 1] except Similarity, which is a cosine.
+type ScoreComponents struct {
+	Similarity float64 `json:"similarity"`
+	Quality    float64 `json:"quality"`
+	Popularity float64 `json:"popularity"`
+	Recency    float64 `json:"recency"`
+}
+
+// RankingWeights overrides the server's ranking weights for one request.
+// Nil fields keep the configured value.
+type RankingWeights struct {
+	Similarity *float64 `json:"similarity,omitempty"`
+	Quality    *float64 `json:"quality,omitempty"`
+	Popularity *float64 `json:"popularity,omitempty"`
+	Recency    *float64 `json:"recency,omitempty"`
+}
+
+// IngestReport counts what an ingestion run did with each fetched anime.
+type IngestReport struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Failed    int `json:"failed"`
+	// Embedded is how many animes needed a new embedding.
+	Embedded int `json:"embedded"`
+	// FailedPages lists AniList pages that could not be fetched or
+	// processed; their animes are not counted anywhere else.
+	FailedPages []int64 `json:"failedPages,omitempty"`
+}
+
+func (r *IngestReport) Add(other IngestReport) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Unchanged += other.Unchanged
+	r.Failed += other.Failed
+	r.Embedded += other.Embedded
+	r.FailedPages = append(r.FailedPages, other.FailedPages...)
+}
+
+// Stored is how many animes are current in the repository after the run.
+func (r IngestReport) Stored() int {
+	return r.Inserted + r.Updated + r.Unchanged
+}
+
+type ReembedStatus struct {
+	Running    bool      `json:"running"`
+	DryRun     bool      `json:"dryRun"`
+	Model      string    `json:"model"`
+	Scanned    int       `json:"scanned"`
+	Stale      int       `json:"stale"`
+	Reembedded int       `json:"reembedded"`
+	Failed     int       `json:"failed"`
+	LastID     int       `json:"lastId"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// AniListBudget reports the shared AniList client's rate limit state.
+// Remaining is -1 until AniList has sent an X-RateLimit-Remaining header.
+type AniListBudget struct {
+	Limit       int       `json:"limit"`
+	Remaining   int       `json:"remaining"`
+	Tokens      float64   `json:"tokens"`
+	PausedUntil time.Time `json:"pausedUntil,omitzero"`
+	Requests    int64     `json:"requests"`
+	Retries     int64     `json:"retries"`
+	RateLimited int64     `json:"rateLimited"`
+}
+
+type SyncStatus struct {
+	Running     bool         `json:"running"`
+	Incremental bool         `json:"incremental"`
+	Query       AniListQuery `json:"query"`
+	// Since is the high-water mark an incremental sync stops at, in Unix
+	// seconds.
+	Since int64 `json:"since,omitempty"`
+	// Page is the last page fetched; LastPage and Total come from AniList's
+	// pageInfo and may grow while the sync runs.
+	Page       int64        `json:"page"`
+	LastPage   int          `json:"lastPage"`
+	Total      int          `json:"total"`
+	Report     IngestReport `json:"report"`
+	StartedAt  time.Time    `json:"startedAt"`
+	FinishedAt time.Time    `json:"finishedAt,omitzero"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// SeedRef identifies a seed anime in a recommendation request. It decodes
+// from either a bare ID or an object with an optional weight.
+type SeedRef struct {
+	ID     int      `json:"id"`
+	Weight *float64 `json:"weight,omitempty"`
+}
+
+func (s *SeedRef) UnmarshalJSON(data []byte) error {
+	var id int
+	if err := json.Unmarshal(data, &id); err == nil {
+		*s = SeedRef{ID: id}
+		return nil
+	}
+
+	type seedRef SeedRef
+	return json.Unmarshal(data, (*seedRef)(s))
+}
+
+type RecommendRequest struct {
+	Likes       []SeedRef   `json:"likes"`
+	Dislikes    []SeedRef   `json:"dislikes"`
+	Query       string      `json:"query"`
+	QueryWeight *float64    `json:"queryWeight,omitempty"`
+	Strategy    string      `json:"strategy"`
+	Limit       int         `json:"limit"`
+	Offset      int         `json:"offset"`
+	MinScore    *float64    `json:"minScore,omitempty"`
+	Filter      AnimeFilter `json:"filter"`
+	// Diversity, MaxPerGenre and MaxPerStudio re-rank results for variety;
+	// see service.RecommendOptions.
+	Diversity    float64 `json:"diversity"`
+	MaxPerGenre  int     `json:"maxPerGenre"`
+	MaxPerStudio int     `json:"maxPerStudio"`
+	// Weights blends similarity with quality, popularity and recency priors.
+	Weights RankingWeights `json:"weights"`
+	Explain bool           `json:"explain"`
+}
+
+// AnimeFilter constrains which animes a search may return. Zero values are
+// ignored. Genres must all be present, while Seasons, Statuses, Sources and
+// Studios match if any listed value matches.
+type AnimeFilter struct {
+	Genres          []string `json:"genres,omitempty"`
+	ExcludeGenres   []string `json:"excludeGenres,omitempty"`
+	MinYear         int      `json:"minYear,omitempty"`
+	MaxYear         int      `json:"maxYear,omitempty"`
+	Seasons         []string `json:"seasons,omitempty"`
+	Statuses        []string `json:"statuses,omitempty"`
+	Sources         []string `json:"sources,omitempty"`
+	Studios         []string `json:"studios,omitempty"`
+	MinAverageScore int      `json:"minAverageScore,omitempty"`
+	MaxAverageScore int      `json:"maxAverageScore,omitempty"`
+	MinEpisodes     int      `json:"minEpisodes,omitempty"`
+	MaxEpisodes     int      `json:"maxEpisodes,omitempty"`
+	MinDuration     int      `json:"minDuration,omitempty"`
+	MaxDuration     int      `json:"maxDuration,omitempty"`
+}
+
+type RankContribution struct {
+	Rank         int     `json:"rank"`
+	Score        float64 `json:"score"`
+	Contribution float64 `json:"contribution"`
+}
+
+type HybridResult struct {
+	Anime       Anime
+	Score       float64
+	Lexical     *RankContribution
+	Semantic    *RankContribution
+	Explanation *Explanation
+}
+
+type SearchResponse struct {
+	AnimeResponse
+	Score       float64           `json:"score"`
+	Lexical     *RankContribution `json:"lexical,omitempty"`
+	Semantic    *RankContribution `json:"semantic,omitempty"`
+	Explanation *Explanation      `json:"explanation,omitempty"`
+}
+
+// SearchPage is the search response when facets are requested.
+type SearchPage struct {
+	Items  []SearchResponse `json:"items"`
+	Facets *Facets          `json:"facets"`
+}
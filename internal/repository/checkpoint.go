@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CheckpointStore persists the progress of long-running jobs so they can
+// resume after a restart.
+type CheckpointStore interface {
+	LoadCheckpoint(ctx context.Context, name string, value any) (bool, error)
+	SaveCheckpoint(ctx context.Context, name string, value any) error
+	DeleteCheckpoint(ctx context.Context, name string) error
+}
+
+type MongoCheckpointStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoCheckpointStore(collection *mongo.Collection) *MongoCheckpointStore {
+	return &MongoCheckpointStore{collection: collection}
+}
+
+func (m *MongoCheckpointStore) LoadCheckpoint(ctx context.Context, name string, value any) (bool, error) {
+	var doc struct {
+		Value bson.RawValue `bson:"value"`
+	}
+	err := m.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("mongo findOne error: %w", err)
+	}
+	if err := doc.Value.Unmarshal(value); err != nil {
+		return false, fmt.Errorf("decode error: %w", err)
+	}
+	return true, nil
+}
+
+func (m *MongoCheckpointStore) SaveCheckpoint(ctx context.Context, name string, value any) error {
+	update := bson.M{"$set": bson.M{"value": value, "updatedAt": time.Now().UTC()}}
+	_, err := m.collection.UpdateOne(ctx, bson.M{"_id": name}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("mongo update error: %w", err)
+	}
+	return nil
+}
+
+func (m *MongoCheckpointStore) DeleteCheckpoint(ctx context.Context, name string) error {
+	if _, err := m.collection.DeleteOne(ctx, bson.M{"_id": name}); err != nil {
+		return fmt.Errorf("mongo delete error: %w", err)
+	}
+	return nil
+}
+
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string][]byte
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string][]byte)}
+}
+
+func (m *MemoryCheckpointStore) LoadCheckpoint(ctx context.Context, name string, value any) (bool, error) {
+	m.mu.Lock()
+	data, ok := m.checkpoints[name]
+	m.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, value)
+}
+
+func (m *MemoryCheckpointStore) SaveCheckpoint(ctx context.Context, name string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[name] = data
+	return nil
+}
+
+func (m *MemoryCheckpointStore) DeleteCheckpoint(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.checkpoints, name)
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MatchFilter reports whether anime satisfies f. Range bounds exclude
+// animes with an unknown (zero) value, matching MongoDB where those fields
+// are omitted.
+func MatchFilter(f models.AnimeFilter, anime models.Anime) bool {
+	for _, genre := range f.Genres {
+		if !containsFold(anime.Genres, genre) {
+			return false
+		}
+	}
+	for _, genre := range f.ExcludeGenres {
+		if containsFold(anime.Genres, genre) {
+			return false
+		}
+	}
+
+	if len(f.Seasons) > 0 && !containsFold(f.Seasons, anime.Season) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !containsFold(f.Statuses, anime.Status) {
+		return false
+	}
+	if len(f.Sources) > 0 && !containsFold(f.Sources, anime.Source) {
+		return false
+	}
+	if len(f.Studios) > 0 && !slices.ContainsFunc(anime.Studios, func(studio string) bool {
+		return containsFold(f.Studios, studio)
+	}) {
+		return false
+	}
+
+	return inRange(anime.SeasonYear, f.MinYear, f.MaxYear) &&
+		inRange(anime.AverageScore, f.MinAverageScore, f.MaxAverageScore) &&
+		inRange(anime.Episodes, f.MinEpisodes, f.MaxEpisodes) &&
+		inRange(anime.Duration, f.MinDuration, f.MaxDuration)
+}
+
+func containsFold(values []string, target string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, target)
+	})
+}
+
+func inRange(value, lo, hi int) bool {
+	if lo == 0 && hi == 0 {
+		return true
+	}
+	if value == 0 {
+		return false
+	}
+	return (lo == 0 || value >= lo) && (hi == 0 || value <= hi)
+}
+
+// mongoFilter translates f into MQL restricted to the operators Atlas
+// $vectorSearch accepts in its pre-filter, so the same document also works
+// with Find and $match. Genres and studios are matched on their lower-cased
+// keys, which ignores case like MatchFilter. It returns nil for an empty
+// filter.
+func mongoFilter(f models.AnimeFilter) bson.M {
+	var clauses []bson.M
+
+	for _, genre := range f.Genres {
+		clauses = append(clauses, bson.M{"genreKeys": bson.M{"$eq": strings.ToLower(genre)}})
+	}
+	if len(f.ExcludeGenres) > 0 {
+		clauses = append(clauses, bson.M{"genreKeys": bson.M{"$nin": lower(f.ExcludeGenres)}})
+	}
+	if len(f.Seasons) > 0 {
+		clauses = append(clauses, bson.M{"season": bson.M{"$in": Upper(f.Seasons)}})
+	}
+	if len(f.Statuses) > 0 {
+		clauses = append(clauses, bson.M{"status": bson.M{"$in": Upper(f.Statuses)}})
+	}
+	if len(f.Sources) > 0 {
+		clauses = append(clauses, bson.M{"source": bson.M{"$in": Upper(f.Sources)}})
+	}
+	if len(f.Studios) > 0 {
+		clauses = append(clauses, bson.M{"studioKeys": bson.M{"$in": lower(f.Studios)}})
+	}
+
+	clauses = appendRange(clauses, "seasonYear", f.MinYear, f.MaxYear)
+	clauses = appendRange(clauses, "averageScore", f.MinAverageScore, f.MaxAverageScore)
+	clauses = appendRange(clauses, "episodes", f.MinEpisodes, f.MaxEpisodes)
+	clauses = appendRange(clauses, "duration", f.MinDuration, f.MaxDuration)
+
+	switch len(clauses) {
+	case 0:
+		return nil
+	case 1:
+		return clauses[0]
+	default:
+		return bson.M{"$and": clauses}
+	}
+}
+
+func appendRange(clauses []bson.M, field string, lo, hi int) []bson.M {
+	if lo == 0 && hi == 0 {
+		return clauses
+	}
+	bounds := bson.M{"$gt": 0}
+	if lo != 0 {
+		bounds = bson.M{"$gte": lo}
+	}
+	if hi != 0 {
+		bounds["$lte"] = hi
+	}
+	return append(clauses, bson.M{field: bounds})
+}
+
+// lower returns lower-cased copies of values, as stored in the genreKeys
+// and studioKeys fields that genre and studio filters match against.
+func lower(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, strings.ToLower(v))
+	}
+	return out
+}
+
+// Upper returns upper-cased copies of values. AniList enums (season, status,
+// source, format) are stored upper-case.
+func Upper(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, strings.ToUpper(v))
+	}
+	return out
+}
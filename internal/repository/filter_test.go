@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"fmt"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var filterFixtures = []models.Anime{
+	{ID: 1, Genres: []string{"Action", "Drama"}, SeasonYear: 2013, Season: "SPRING", Status: "FINISHED", Source: "MANGA", Studios: []string{"Wit Studio"}, AverageScore: 85, Episodes: 25, Duration: 24},
+	{ID: 2, Genres: []string{"Comedy", "Slice of Life"}, SeasonYear: 2019, Season: "FALL", Status: "FINISHED", Source: "ORIGINAL", Studios: []string{"Kyoto Animation"}, AverageScore: 78, Episodes: 12, Duration: 23},
+	{ID: 3, Genres: []string{"action", "Sci-Fi"}, SeasonYear: 2024, Season: "WINTER", Status: "RELEASING", Source: "LIGHT_NOVEL", Studios: []string{"MAPPA", "wit studio"}, AverageScore: 71},
+	{ID: 4, Genres: []string{"Romance"}, Status: "NOT_YET_RELEASED", Source: "MANGA"},
+	{ID: 5, SeasonYear: 1998, Season: "SPRING", Status: "FINISHED", Source: "ORIGINAL", Studios: []string{"Sunrise"}, AverageScore: 86, Episodes: 26, Duration: 24},
+}
+
+func TestMatchFilterMongoFilterParity(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter models.AnimeFilter
+		want   []int
+	}{
+		{"empty", models.AnimeFilter{}, []int{1, 2, 3, 4, 5}},
+		{"genre ignores case", models.AnimeFilter{Genres: []string{"ACTION"}}, []int{1, 3}},
+		{"all genres required", models.AnimeFilter{Genres: []string{"action", "drama"}}, []int{1}},
+		{"exclude genre", models.AnimeFilter{ExcludeGenres: []string{"Action"}}, []int{2, 4, 5}},
+		{"season", models.AnimeFilter{Seasons: []string{"spring"}}, []int{1, 5}},
+		{"status", models.AnimeFilter{Statuses: []string{"Releasing", "not_yet_released"}}, []int{3, 4}},
+		{"source", models.AnimeFilter{Sources: []string{"manga"}}, []int{1, 4}},
+		{"studio ignores case", models.AnimeFilter{Studios: []string{"WIT STUDIO"}}, []int{1, 3}},
+		{"any studio", models.AnimeFilter{Studios: []string{"Sunrise", "Kyoto Animation"}}, []int{2, 5}},
+		{"min year", models.AnimeFilter{MinYear: 2019}, []int{2, 3}},
+		{"max year skips unknown", models.AnimeFilter{MaxYear: 2013}, []int{1, 5}},
+		{"year range", models.AnimeFilter{MinYear: 2000, MaxYear: 2020}, []int{1, 2}},
+		{"score range", models.AnimeFilter{MinAverageScore: 75, MaxAverageScore: 85}, []int{1, 2}},
+		{"max episodes skips unknown", models.AnimeFilter{MaxEpisodes: 13}, []int{2}},
+		{"duration", models.AnimeFilter{MinDuration: 24}, []int{1, 5}},
+		{"combined", models.AnimeFilter{Genres: []string{"action"}, Studios: []string{"mappa"}, MinYear: 2020}, []int{3}},
+		{"no match", models.AnimeFilter{Genres: []string{"Horror"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := mongoFilter(tt.filter)
+			var memory, mongo []int
+			for _, anime := range filterFixtures {
+				if MatchFilter(tt.filter, anime) {
+					memory = append(memory, anime.ID)
+				}
+				ok, err := evalMQL(mongoDocument(t, anime), query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok {
+					mongo = append(mongo, anime.ID)
+				}
+			}
+			if !slices.Equal(memory, tt.want) {
+				t.Errorf("MatchFilter matched %v, want %v", memory, tt.want)
+			}
+			if !slices.Equal(mongo, tt.want) {
+				t.Errorf("mongoFilter %v matched %v, want %v", query, mongo, tt.want)
+			}
+		})
+	}
+}
+
+// mongoDocument returns anime as it is stored by MongoAnimeRepository.
+func mongoDocument(t *testing.T, anime models.Anime) bson.M {
+	t.Helper()
+	raw, err := bson.Marshal(newMongoAnime(anime))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// evalMQL evaluates the subset of MQL that mongoFilter produces against doc,
+// following MongoDB's rules for missing fields and array values.
+func evalMQL(doc bson.M, query bson.M) (bool, error) {
+	for key, cond := range query {
+		switch key {
+		case "$and", "$or":
+			clauses, ok := cond.([]bson.M)
+			if !ok {
+				return false, fmt.Errorf("%s: unexpected clauses %T", key, cond)
+			}
+			any := false
+			for _, clause := range clauses {
+				ok, err := evalMQL(doc, clause)
+				if err != nil {
+					return false, err
+				}
+				if key == "$and" && !ok {
+					return false, nil
+				}
+				any = any || ok
+			}
+			if key == "$or" && !any {
+				return false, nil
+			}
+		default:
+			ops, ok := cond.(bson.M)
+			if !ok {
+				return false, fmt.Errorf("%s: unexpected condition %T", key, cond)
+			}
+			value, present := doc[key]
+			for op, arg := range ops {
+				ok, err := evalOp(op, value, present, arg)
+				if err != nil {
+					return false, fmt.Errorf("%s: %w", key, err)
+				}
+				if !ok {
+					return false, nil
+				}
+			}
+		}
+	}
+	return true, nil
+}
+
+func evalOp(op string, value any, present bool, arg any) (bool, error) {
+	values := []any{value}
+	if array, ok := value.(bson.A); ok {
+		values = array
+	}
+	anyValue := func(match func(any) bool) bool {
+		return present && slices.ContainsFunc(values, match)
+	}
+
+	switch op {
+	case "$eq":
+		return anyValue(func(v any) bool { return equalValue(v, arg) }), nil
+	case "$in", "$nin":
+		list, ok := arg.([]string)
+		if !ok {
+			return false, fmt.Errorf("%s: unexpected list %T", op, arg)
+		}
+		in := anyValue(func(v any) bool {
+			return slices.ContainsFunc(list, func(s string) bool { return equalValue(v, s) })
+		})
+		return in == (op == "$in"), nil
+	case "$gt", "$gte", "$lte":
+		bound, ok := arg.(int)
+		if !ok {
+			return false, fmt.Errorf("%s: unexpected bound %T", op, arg)
+		}
+		return anyValue(func(v any) bool {
+			n, ok := v.(int32)
+			switch {
+			case !ok:
+				return false
+			case op == "$gt":
+				return int(n) > bound
+			case op == "$gte":
+				return int(n) >= bound
+			default:
+				return int(n) <= bound
+			}
+		}), nil
+	default:
+		return false, fmt.Errorf("unsupported operator %s", op)
+	}
+}
+
+func equalValue(v, arg any) bool {
+	s, ok := v.(string)
+	return ok && s == arg
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"anime/internal/vectorindex"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IndexedAnimeRepository wraps any AnimeRepository with an in-process HNSW
+// index for VectorSearch. Writes go to the wrapped backend first and are
+// then applied to the index, so it stays current without Atlas.
+type IndexedAnimeRepository struct {
+	AnimeRepository
+	index   *vectorindex.HNSW
+	model   string
+	version string
+	path    string
+	dirty   atomic.Bool
+
+	// catalog holds the indexed documents without their embeddings, so
+	// search results and filters are resolved without backend round trips.
+	catalogMu sync.RWMutex
+	catalog   map[int]models.Anime
+}
+
+type IndexOptions struct {
+	Config vectorindex.Config
+	// Model and Version identify the embedding model whose vectors are
+	// indexed.
+	Model   string
+	Version string
+	// Path, when set, is where the index is loaded from and saved to.
+	Path string
+}
+
+// NewIndexedAnimeRepository loads the index from opts.Path when it was built
+// for the same model, then reconciles it against the backend so documents
+// written while the server was down are picked up.
+func NewIndexedAnimeRepository(ctx context.Context, inner AnimeRepository, opts IndexOptions) (*IndexedAnimeRepository, error) {
+	r := &IndexedAnimeRepository{
+		AnimeRepository: inner,
+		model:           opts.Model,
+		version:         opts.Version,
+		path:            opts.Path,
+		catalog:         make(map[int]models.Anime),
+	}
+
+	if opts.Path != "" {
+		index, model, err := vectorindex.LoadFile(opts.Path)
+		switch {
+		case err == nil && model == r.fileModel():
+			index.SetEfSearch(opts.Config.EfSearch)
+			r.index = index
+			log.Printf("Loaded vector index with %d vectors from %s\n", index.Len(), opts.Path)
+		case err == nil:
+			log.Printf("Ignoring vector index at %s built for model %q\n", opts.Path, model)
+		case !errors.Is(err, os.ErrNotExist):
+			log.Println("load vector index error:", err)
+		}
+	}
+	if r.index == nil {
+		r.index = vectorindex.New(opts.Config)
+	}
+
+	if err := r.Rebuild(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Rebuild inserts every indexable document that is missing from the index
+// or whose embedding changed since it was indexed, and drops entries whose
+// documents no longer exist in the backend.
+func (r *IndexedAnimeRepository) Rebuild(ctx context.Context) error {
+	start := time.Now()
+	added, lastID := 0, 0
+	stored := make(map[int]bool)
+	for {
+		batch, err := r.AnimeRepository.Scan(ctx, lastID, 500)
+		if err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, anime := range batch {
+			stored[anime.ID] = true
+			ok, err := r.indexAnime(anime)
+			if err != nil {
+				log.Printf("index error for anime %d: %v", anime.ID, err)
+			}
+			if ok {
+				added++
+			}
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+
+	removed := 0
+	for _, id := range r.index.IDs() {
+		if !stored[id] {
+			r.remove(id)
+			removed++
+		}
+	}
+
+	log.Printf("Vector index ready: %d vectors (%d updated, %d removed) in %s\n", r.index.Len(), added, removed, time.Since(start))
+	if added > 0 || removed > 0 {
+		r.dirty.Store(true)
+		return r.Save()
+	}
+	return nil
+}
+
+func (r *IndexedAnimeRepository) indexAnime(anime models.Anime) (bool, error) {
+	if !r.indexable(anime) {
+		r.remove(anime.ID)
+		return false, nil
+	}
+
+	entry := anime
+	entry.Embedding = nil
+	r.catalogMu.Lock()
+	r.catalog[anime.ID] = entry
+	r.catalogMu.Unlock()
+
+	stamp := embeddingStamp(anime)
+	if current, ok := r.index.Stamp(anime.ID); ok && current == stamp {
+		return false, nil
+	}
+	return true, r.index.Insert(anime.ID, anime.Embedding, stamp)
+}
+
+func (r *IndexedAnimeRepository) remove(id int) {
+	r.index.Delete(id)
+	r.catalogMu.Lock()
+	delete(r.catalog, id)
+	r.catalogMu.Unlock()
+}
+
+func (r *IndexedAnimeRepository) indexable(anime models.Anime) bool {
+	if len(anime.Embedding) == 0 {
+		return false
+	}
+	if dims := r.index.Dimensions(); dims != 0 && dims != len(anime.Embedding) {
+		return false
+	}
+	return anime.EmbeddingInfo == nil || SameEmbeddingModel(anime.EmbeddingInfo, r.model, r.version)
+}
+
+// fileModel is the model recorded in the saved index, including the version
+// so an index built from another version's vectors is not loaded.
+func (r *IndexedAnimeRepository) fileModel() string {
+	if r.version == "" {
+		return r.model
+	}
+	return r.model + "@" + r.version
+}
+
+// embeddingStamp uses millisecond precision because that is what MongoDB
+// keeps for dates, so stamps read back from the backend still match.
+func embeddingStamp(anime models.Anime) int64 {
+	if anime.EmbeddingInfo == nil {
+		return 0
+	}
+	return anime.EmbeddingInfo.CreatedAt.UnixMilli()
+}
+
+func (r *IndexedAnimeRepository) Upsert(ctx context.Context, animes []models.Anime) (int, error) {
+	n, err := r.AnimeRepository.Upsert(ctx, animes)
+	if err != nil {
+		return n, err
+	}
+
+	for _, anime := range animes {
+		ok, err := r.indexAnime(anime)
+		if err != nil {
+			log.Printf("index error for anime %d: %v", anime.ID, err)
+		}
+		if ok {
+			r.dirty.Store(true)
+		}
+	}
+	return n, nil
+}
+
+// VectorSearch answers from the index when the query uses the indexed model.
+// Filtered queries widen the candidate list until a full page of matches is
+// found or the whole index has been considered.
+func (r *IndexedAnimeRepository) VectorSearch(ctx context.Context, query VectorQuery) ([]models.ScoredAnime, error) {
+	if (query.Model != "" && (query.Model != r.model || query.Version != r.version)) || len(query.Vector) != r.index.Dimensions() {
+		return r.AnimeRepository.VectorSearch(ctx, query)
+	}
+
+	k := query.Limit
+	ef := max(query.NumCandidates, k)
+	for {
+		hits, err := r.index.Search(query.Vector, k, ef)
+		if err != nil {
+			return nil, fmt.Errorf("vector index search error: %w", err)
+		}
+
+		results := r.resolve(hits, query)
+		// Tombstones can leave a search short of k hits, so only a k that
+		// covers the whole index means there is nothing left to widen to.
+		if len(results) >= query.Limit || k >= r.index.Len() {
+			if len(results) > query.Limit {
+				results = results[:query.Limit]
+			}
+			return results, nil
+		}
+
+		k *= 4
+		ef = max(ef, k)
+	}
+}
+
+func (r *IndexedAnimeRepository) resolve(hits []vectorindex.Result, query VectorQuery) []models.ScoredAnime {
+	r.catalogMu.RLock()
+	defer r.catalogMu.RUnlock()
+
+	results := make([]models.ScoredAnime, 0, len(hits))
+	for _, hit := range hits {
+		anime, ok := r.catalog[hit.ID]
+		if !ok || !MatchFilter(query.Filter, anime) {
+			continue
+		}
+		anime.Embedding, _ = r.index.Vector(hit.ID)
+		results = append(results, models.ScoredAnime{Anime: anime, Score: hit.Score})
+	}
+	return results
+}
+
+// Save compacts the index once replaced and deleted vectors pile up, then
+// writes it to its configured path if it changed since the last save.
+func (r *IndexedAnimeRepository) Save() error {
+	if r.index.NeedsCompaction() {
+		start := time.Now()
+		tombstones := r.index.Tombstones()
+		r.index.Compact()
+		r.dirty.Store(true)
+		log.Printf("Compacted vector index: dropped %d tombstones in %s\n", tombstones, time.Since(start))
+	}
+
+	if r.path == "" || !r.dirty.Swap(false) {
+		return nil
+	}
+	if err := r.index.SaveFile(r.path, r.fileModel()); err != nil {
+		r.dirty.Store(true)
+		return err
+	}
+	log.Printf("Saved vector index with %d vectors to %s\n", r.index.Len(), r.path)
+	return nil
+}
+
+// AutoSave persists the index every interval until ctx is cancelled.
+func (r *IndexedAnimeRepository) AutoSave(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Save(); err != nil {
+				log.Println("save vector index error:", err)
+			}
+		}
+	}
+}
+
+// MeasureRecall samples stored vectors as queries and compares index
+// results with an exact scan over the whole index.
+func (r *IndexedAnimeRepository) MeasureRecall(queries int, k int, ef int) (vectorindex.RecallReport, error) {
+	vectors := r.index.Vectors()
+	sample := make([][]float32, 0, queries)
+	for _, v := range vectors {
+		if len(sample) == queries {
+			break
+		}
+		sample = append(sample, v)
+	}
+	return vectorindex.MeasureRecall(r.index, vectors, sample, k, ef)
+}
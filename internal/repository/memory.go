@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"anime/internal/textindex"
+	"anime/internal/utils"
+	"cmp"
+	"context"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type MemoryAnimeRepository struct {
+	mu     sync.RWMutex
+	animes map[int]models.Anime
+	order  []int
+	text   *textindex.BM25
+}
+
+func NewMemoryAnimeRepository() *MemoryAnimeRepository {
+	return &MemoryAnimeRepository{animes: make(map[int]models.Anime), text: textindex.New()}
+}
+
+func (m *MemoryAnimeRepository) List(ctx context.Context) ([]models.Anime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	animes := make([]models.Anime, 0, len(m.order))
+	for _, id := range m.order {
+		animes = append(animes, m.animes[id])
+	}
+	return animes, nil
+}
+
+func (m *MemoryAnimeRepository) Scan(ctx context.Context, afterID int, limit int) ([]models.Anime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []int
+	for id := range m.animes {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	animes := make([]models.Anime, 0, len(ids))
+	for _, id := range ids {
+		animes = append(animes, m.animes[id])
+	}
+	return animes, nil
+}
+
+func (m *MemoryAnimeRepository) ListPage(ctx context.Context, query ListQuery) ([]models.Anime, int, error) {
+	m.mu.RLock()
+	var matches []models.Anime
+	for _, id := range m.order {
+		if anime := m.animes[id]; MatchFilter(query.Filter, anime) {
+			matches = append(matches, anime)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if c := compareField(matches[i], matches[j], query.Sort); c != 0 {
+			return (c < 0) != query.Descending
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	total := len(matches)
+	page := []models.Anime{}
+	if query.Offset < total {
+		page = matches[query.Offset:min(total, query.Offset+query.Limit)]
+	}
+	if !query.IncludeEmbedding {
+		for i := range page {
+			page[i].Embedding = nil
+		}
+	}
+	return page, total, nil
+}
+
+// compareField orders animes by a list sort key the way MongoDB does,
+// with unknown (zero) values lowest.
+func compareField(a, b models.Anime, sortKey string) int {
+	switch sortKey {
+	case SortScore:
+		return cmp.Compare(a.AverageScore, b.AverageScore)
+	case SortYear:
+		return cmp.Compare(a.SeasonYear, b.SeasonYear)
+	case SortTitle:
+		return cmp.Compare(a.Title.Romaji, b.Title.Romaji)
+	case SortEpisodes:
+		return cmp.Compare(a.Episodes, b.Episodes)
+	}
+	return 0
+}
+
+func (m *MemoryAnimeRepository) GetByID(ctx context.Context, id int) (models.Anime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	anime, ok := m.animes[id]
+	if !ok {
+		return models.Anime{}, ErrNotFound
+	}
+	return anime, nil
+}
+
+func (m *MemoryAnimeRepository) GetByIDs(ctx context.Context, ids []int, includeEmbedding bool) ([]models.Anime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var animes []models.Anime
+	for _, id := range ids {
+		anime, ok := m.animes[id]
+		if !ok {
+			continue
+		}
+		if !includeEmbedding {
+			anime.Embedding = nil
+		}
+		animes = append(animes, anime)
+	}
+	return animes, nil
+}
+
+func (m *MemoryAnimeRepository) GetByName(ctx context.Context, name string) (models.Anime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range m.order {
+		anime := m.animes[id]
+		if strings.EqualFold(anime.Title.Romaji, name) || strings.EqualFold(anime.Title.English, name) {
+			return anime, nil
+		}
+	}
+	return models.Anime{}, ErrNotFound
+}
+
+func (m *MemoryAnimeRepository) Random(ctx context.Context) (models.Anime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.order) == 0 {
+		return models.Anime{}, ErrNotFound
+	}
+	return m.animes[m.order[rand.Intn(len(m.order))]], nil
+}
+
+func (m *MemoryAnimeRepository) TopRated(ctx context.Context, limit int64) ([]models.Anime, error) {
+	animes, _ := m.List(ctx)
+	sort.SliceStable(animes, func(i, j int) bool {
+		return animes[i].AverageScore > animes[j].AverageScore
+	})
+
+	if limit > 0 && int64(len(animes)) > limit {
+		animes = animes[:limit]
+	}
+	return animes, nil
+}
+
+func (m *MemoryAnimeRepository) Upsert(ctx context.Context, animes []models.Anime) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, anime := range animes {
+		if _, ok := m.animes[anime.ID]; !ok {
+			m.order = append(m.order, anime.ID)
+		}
+		m.animes[anime.ID] = anime
+		m.text.Add(anime.ID, textFields(anime)...)
+	}
+	return len(animes), nil
+}
+
+func (m *MemoryAnimeRepository) VectorSearch(ctx context.Context, query VectorQuery) ([]models.ScoredAnime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []models.ScoredAnime
+	for _, id := range m.order {
+		anime := m.animes[id]
+		if !EmbeddingCompatible(anime, query.Model, query.Version, len(query.Vector)) || !MatchFilter(query.Filter, anime) {
+			continue
+		}
+
+		similarity, err := utils.CosineSimilarity(query.Vector, anime.Embedding)
+		if err != nil {
+			continue
+		}
+		results = append(results, models.ScoredAnime{Anime: anime, Score: similarity})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+func (m *MemoryAnimeRepository) TextSearch(ctx context.Context, query TextQuery) ([]models.ScoredAnime, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hits := m.text.Search(query.Text, query.Limit, func(id int) bool {
+		return MatchFilter(query.Filter, m.animes[id])
+	})
+
+	results := make([]models.ScoredAnime, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, models.ScoredAnime{Anime: m.animes[hit.ID], Score: hit.Score})
+	}
+	return results, nil
+}
+
+// textFields weights titles above genres and studios, and those above the
+// description, so keyword matches on names rank first.
+func textFields(anime models.Anime) []textindex.Field {
+	return []textindex.Field{
+		{Text: anime.Title.Romaji, Weight: 3},
+		{Text: anime.Title.English, Weight: 3},
+		{Text: strings.Join(anime.Genres, " "), Weight: 2},
+		{Text: strings.Join(anime.Studios, " "), Weight: 2},
+		{Text: anime.Description, Weight: 1},
+	}
+}
+
+func (m *MemoryAnimeRepository) EmbeddingModelCounts(ctx context.Context) ([]models.EmbeddingModelCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type key struct {
+		model      string
+		dimensions int
+	}
+	counts := make(map[key]int)
+	for _, anime := range m.animes {
+		k := key{dimensions: len(anime.Embedding)}
+		if anime.EmbeddingInfo != nil {
+			k.model = anime.EmbeddingInfo.Model
+		}
+		counts[k]++
+	}
+
+	report := make([]models.EmbeddingModelCount, 0, len(counts))
+	for k, count := range counts {
+		report = append(report, models.EmbeddingModelCount{Model: k.model, Dimensions: k.dimensions, Count: count})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Count > report[j].Count
+	})
+	return report, nil
+}
+
+func (m *MemoryAnimeRepository) Facets(ctx context.Context, filter models.AnimeFilter) (models.Facets, error) {
+	genres := make(map[string]int)
+	statuses := make(map[string]int)
+	seasons := make(map[string]int)
+	sources := make(map[string]int)
+	studios := make(map[string]int)
+
+	m.mu.RLock()
+	for _, anime := range m.animes {
+		if !MatchFilter(filter, anime) {
+			continue
+		}
+		for _, genre := range anime.Genres {
+			genres[genre]++
+		}
+		for _, studio := range anime.Studios {
+			studios[studio]++
+		}
+		statuses[anime.Status]++
+		seasons[anime.Season]++
+		sources[anime.Source]++
+	}
+	m.mu.RUnlock()
+
+	facets := models.Facets{
+		Genres:   facetCounts(genres),
+		Statuses: facetCounts(statuses),
+		Seasons:  facetCounts(seasons),
+		Sources:  facetCounts(sources),
+		Studios:  facetCounts(studios),
+	}
+	sortFacets(&facets)
+	return facets, nil
+}
+
+func facetCounts(counts map[string]int) []models.FacetCount {
+	facet := make([]models.FacetCount, 0, len(counts))
+	for value, count := range counts {
+		if value != "" {
+			facet = append(facet, models.FacetCount{Value: value, Count: count})
+		}
+	}
+	return facet
+}
+
+func (m *MemoryAnimeRepository) StudioStats(ctx context.Context) ([]models.StudioStats, error) {
+	type totals struct {
+		count, scored, scoreSum int
+	}
+	byStudio := make(map[string]*totals)
+
+	m.mu.RLock()
+	for _, anime := range m.animes {
+		for _, studio := range anime.Studios {
+			t, ok := byStudio[studio]
+			if !ok {
+				t = &totals{}
+				byStudio[studio] = t
+			}
+			t.count++
+			if anime.AverageScore > 0 {
+				t.scored++
+				t.scoreSum += anime.AverageScore
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	stats := make([]models.StudioStats, 0, len(byStudio))
+	for studio, t := range byStudio {
+		s := models.StudioStats{Name: studio, Count: t.count}
+		if t.scored > 0 {
+			s.AverageScore = float64(t.scoreSum) / float64(t.scored)
+		}
+		stats = append(stats, s)
+	}
+	sortStudioStats(stats)
+	return stats, nil
+}
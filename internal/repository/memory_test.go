@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"context"
+	"slices"
+	"testing"
+)
+
+func newFixtureRepository(t *testing.T) *MemoryAnimeRepository {
+	t.Helper()
+	repo := NewMemoryAnimeRepository()
+	if _, err := repo.Upsert(context.Background(), filterFixtures); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestMemoryListPage(t *testing.T) {
+	repo := newFixtureRepository(t)
+
+	tests := []struct {
+		name      string
+		query     ListQuery
+		wantIDs   []int
+		wantTotal int
+	}{
+		{"by id", ListQuery{Limit: 10}, []int{1, 2, 3, 4, 5}, 5},
+		{"paged", ListQuery{Offset: 2, Limit: 2}, []int{3, 4}, 5},
+		{"past the end", ListQuery{Offset: 10, Limit: 2}, []int{}, 5},
+		{"score descending", ListQuery{Sort: SortScore, Descending: true, Limit: 3}, []int{5, 1, 2}, 5},
+		{"unknown year lowest", ListQuery{Sort: SortYear, Limit: 2}, []int{4, 5}, 5},
+		{"filtered", ListQuery{Filter: models.AnimeFilter{Genres: []string{"action"}}, Sort: SortYear, Descending: true, Limit: 10}, []int{3, 1}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, total, err := repo.ListPage(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []int{}
+			for _, anime := range page {
+				ids = append(ids, anime.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || total != tt.wantTotal {
+				t.Errorf("got %v (total %d), want %v (total %d)", ids, total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestMemoryFacets(t *testing.T) {
+	repo := newFixtureRepository(t)
+
+	facets, err := repo.Facets(context.Background(), models.AnimeFilter{Sources: []string{"manga", "original"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		facet []models.FacetCount
+		want  []models.FacetCount
+	}{
+		{"statuses", facets.Statuses, []models.FacetCount{{Value: "FINISHED", Count: 3}, {Value: "NOT_YET_RELEASED", Count: 1}}},
+		{"sources", facets.Sources, []models.FacetCount{{Value: "MANGA", Count: 2}, {Value: "ORIGINAL", Count: 2}}},
+		{"seasons", facets.Seasons, []models.FacetCount{{Value: "SPRING", Count: 2}, {Value: "FALL", Count: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !slices.Equal(tt.facet, tt.want) {
+				t.Errorf("got %v, want %v", tt.facet, tt.want)
+			}
+		})
+	}
+}
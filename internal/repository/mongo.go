@@ -0,0 +1,639 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAnimeRepository struct {
+	collection  *mongo.Collection
+	vectorIndex string
+	// modelFilter and versionFilter are set when the vector index declares
+	// embeddingInfo.model and embeddingInfo.version as filter fields, so the
+	// model can be part of the pre-filter.
+	modelFilter   bool
+	versionFilter bool
+}
+
+func NewMongoAnimeRepository(collection *mongo.Collection, vectorIndex string) *MongoAnimeRepository {
+	return &MongoAnimeRepository{collection: collection, vectorIndex: vectorIndex}
+}
+
+// EnsureIndexes creates the indexes the repository relies on. Creating an
+// index that already exists with the same definition is a no-op.
+func (m *MongoAnimeRepository) EnsureIndexes(ctx context.Context) error {
+	textIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "title.romaji", Value: "text"},
+			{Key: "title.english", Value: "text"},
+			{Key: "genres", Value: "text"},
+			{Key: "studios", Value: "text"},
+			{Key: "description", Value: "text"},
+		},
+		Options: options.Index().SetName("anime_text").SetWeights(bson.D{
+			{Key: "title.romaji", Value: 3},
+			{Key: "title.english", Value: 3},
+			{Key: "genres", Value: 2},
+			{Key: "studios", Value: 2},
+			{Key: "description", Value: 1},
+		}),
+	}
+
+	idIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetName("anime_id").SetUnique(true),
+	}
+
+	if _, err := m.collection.Indexes().CreateOne(ctx, textIndex); err != nil {
+		return fmt.Errorf("create indexes error: %w", err)
+	}
+	if err := m.backfillKeys(ctx); err != nil {
+		return err
+	}
+
+	_, err := m.collection.Indexes().CreateOne(ctx, idIndex)
+	if !mongo.IsDuplicateKeyError(err) {
+		if err != nil {
+			return fmt.Errorf("create indexes error: %w", err)
+		}
+		return nil
+	}
+
+	// Only a collection that older ingestion left with duplicate IDs gets
+	// here; once the index exists, duplicates cannot be inserted again.
+	removed, err := m.removeDuplicates(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Removed %d duplicate anime documents to create the unique id index\n", removed)
+
+	if _, err := m.collection.Indexes().CreateOne(ctx, idIndex); err != nil {
+		return fmt.Errorf("create indexes error: %w", err)
+	}
+	return nil
+}
+
+// InspectVectorIndex reads the definition of the Atlas vector index and
+// records whether it declares embeddingInfo.model and embeddingInfo.version
+// as filter fields. Until it has, VectorSearch filters on the model after
+// retrieval instead.
+func (m *MongoAnimeRepository) InspectVectorIndex(ctx context.Context) error {
+	cursor, err := m.collection.SearchIndexes().List(ctx, options.SearchIndexes().SetName(m.vectorIndex))
+	if err != nil {
+		return fmt.Errorf("list search indexes error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var indexes []struct {
+		LatestDefinition struct {
+			Fields []struct {
+				Type string `bson:"type"`
+				Path string `bson:"path"`
+			} `bson:"fields"`
+		} `bson:"latestDefinition"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+
+	m.modelFilter, m.versionFilter = false, false
+	for _, index := range indexes {
+		for _, field := range index.LatestDefinition.Fields {
+			if field.Type != "filter" {
+				continue
+			}
+			switch field.Path {
+			case "embeddingInfo.model":
+				m.modelFilter = true
+			case "embeddingInfo.version":
+				m.versionFilter = true
+			}
+		}
+	}
+	return nil
+}
+
+// backfillKeys adds genreKeys and studioKeys to documents written before
+// they existed. Once every document has them, the update matches nothing.
+func (m *MongoAnimeRepository) backfillKeys(ctx context.Context) error {
+	lowered := func(field string) bson.M {
+		return bson.M{"$map": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$" + field, bson.A{}}},
+			"in":    bson.M{"$toLower": "$$this"},
+		}}
+	}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"genres.0": bson.M{"$exists": true}, "genreKeys": bson.M{"$exists": false}},
+		bson.M{"studios.0": bson.M{"$exists": true}, "studioKeys": bson.M{"$exists": false}},
+	}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"genreKeys":  lowered("genres"),
+		"studioKeys": lowered("studios"),
+	}}}}
+
+	res, err := m.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("backfill filter keys error: %w", err)
+	}
+	if res.ModifiedCount > 0 {
+		log.Printf("Added genre and studio filter keys to %d anime documents\n", res.ModifiedCount)
+	}
+	return nil
+}
+
+// removeDuplicates deletes all but the most recently inserted document for
+// every AniList ID stored more than once, which older ingestion left
+// behind and which would block the unique index on id.
+func (m *MongoAnimeRepository) removeDuplicates(ctx context.Context) (int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: -1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$id"},
+			{Key: "docs", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+
+	cursor, err := m.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return 0, fmt.Errorf("mongo aggregate error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var stale bson.A
+	for cursor.Next(ctx) {
+		var group struct {
+			ID   int    `bson:"_id"`
+			Docs bson.A `bson:"docs"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return 0, fmt.Errorf("decode error: %w", err)
+		}
+		log.Printf("Removing duplicates of anime %d, keeping %v: %v\n", group.ID, group.Docs[0], group.Docs[1:])
+		stale = append(stale, group.Docs[1:]...)
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, fmt.Errorf("cursor error: %w", err)
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	res, err := m.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": stale}})
+	if err != nil {
+		return 0, fmt.Errorf("mongo delete error: %w", err)
+	}
+	return int(res.DeletedCount), nil
+}
+
+func (m *MongoAnimeRepository) List(ctx context.Context) ([]models.Anime, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("mongo find error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var animes []models.Anime
+	for cursor.Next(ctx) {
+		var anime models.Anime
+		if err := cursor.Decode(&anime); err != nil {
+			log.Println("decode error:", err)
+			continue
+		}
+		animes = append(animes, anime)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return animes, nil
+}
+
+func (m *MongoAnimeRepository) Scan(ctx context.Context, afterID int, limit int) ([]models.Anime, error) {
+	opts := options.Find().SetSort(bson.M{"id": 1}).SetLimit(int64(limit))
+	cursor, err := m.collection.Find(ctx, bson.M{"id": bson.M{"$gt": afterID}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongo find error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var animes []models.Anime
+	if err := cursor.All(ctx, &animes); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+	return animes, nil
+}
+
+func (m *MongoAnimeRepository) ListPage(ctx context.Context, query ListQuery) ([]models.Anime, int, error) {
+	filter := mongoFilter(query.Filter)
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("mongo count error: %w", err)
+	}
+
+	order := bson.D{}
+	if field, ok := sortFields[query.Sort]; ok {
+		direction := 1
+		if query.Descending {
+			direction = -1
+		}
+		order = append(order, bson.E{Key: field, Value: direction})
+	}
+	order = append(order, bson.E{Key: "id", Value: 1})
+
+	opts := options.Find().SetSort(order).SetSkip(int64(query.Offset)).SetLimit(int64(query.Limit))
+	if !query.IncludeEmbedding {
+		opts.SetProjection(bson.M{"embedding": 0})
+	}
+
+	cursor, err := m.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("mongo find error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	animes := []models.Anime{}
+	if err := cursor.All(ctx, &animes); err != nil {
+		return nil, 0, fmt.Errorf("cursor error: %w", err)
+	}
+	return animes, int(total), nil
+}
+
+func (m *MongoAnimeRepository) GetByID(ctx context.Context, id int) (models.Anime, error) {
+	return m.findOne(ctx, bson.M{"id": id})
+}
+
+// GetByIDs returns the stored animes among ids in no particular order;
+// unknown IDs are skipped.
+func (m *MongoAnimeRepository) GetByIDs(ctx context.Context, ids []int, includeEmbedding bool) ([]models.Anime, error) {
+	opts := options.Find()
+	if !includeEmbedding {
+		opts.SetProjection(bson.M{"embedding": 0})
+	}
+
+	cursor, err := m.collection.Find(ctx, bson.M{"id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongo find error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var animes []models.Anime
+	if err := cursor.All(ctx, &animes); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+	return animes, nil
+}
+
+// GetByName matches romaji or English titles exactly, ignoring case and
+// diacritics through a primary-strength collation.
+func (m *MongoAnimeRepository) GetByName(ctx context.Context, name string) (models.Anime, error) {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"title.romaji": name},
+			{"title.english": name},
+		},
+	}
+	return m.findOne(ctx, filter, options.FindOne().SetCollation(&options.Collation{Locale: "en", Strength: 1}))
+}
+
+func (m *MongoAnimeRepository) findOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (models.Anime, error) {
+	var anime models.Anime
+	err := m.collection.FindOne(ctx, filter, opts...).Decode(&anime)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Anime{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Anime{}, fmt.Errorf("mongo findOne error: %w", err)
+	}
+	return anime, nil
+}
+
+func (m *MongoAnimeRepository) Random(ctx context.Context) (models.Anime, error) {
+	count, err := m.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return models.Anime{}, fmt.Errorf("mongo count error: %w", err)
+	}
+
+	if count == 0 {
+		return models.Anime{}, ErrNotFound
+	}
+
+	randomIndex := rand.Int63n(count)
+
+	cursor, err := m.collection.Find(ctx, bson.M{}, options.Find().SetSkip(randomIndex).SetLimit(1))
+	if err != nil {
+		return models.Anime{}, fmt.Errorf("mongo find error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		var anime models.Anime
+		if err := cursor.Decode(&anime); err != nil {
+			return models.Anime{}, fmt.Errorf("decode error: %w", err)
+		}
+		return anime, nil
+	}
+	return models.Anime{}, ErrNotFound
+}
+
+func (m *MongoAnimeRepository) TopRated(ctx context.Context, limit int64) ([]models.Anime, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"averageScore": -1}).SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("mongo find error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var animes []models.Anime
+	for cursor.Next(ctx) {
+		var anime models.Anime
+		if err := cursor.Decode(&anime); err != nil {
+			return animes, fmt.Errorf("decode error: %w", err)
+		}
+		animes = append(animes, anime)
+	}
+
+	return animes, nil
+}
+
+// mongoAnime is the stored form of an anime. The lower-cased keys let
+// genre and studio filters ignore case, which collations cannot do inside
+// $vectorSearch pre-filters. Reads decode into models.Anime and skip them.
+type mongoAnime struct {
+	models.Anime `bson:",inline"`
+	GenreKeys    []string `bson:"genreKeys,omitempty"`
+	StudioKeys   []string `bson:"studioKeys,omitempty"`
+}
+
+func newMongoAnime(anime models.Anime) mongoAnime {
+	return mongoAnime{Anime: anime, GenreKeys: lower(anime.Genres), StudioKeys: lower(anime.Studios)}
+}
+
+func (m *MongoAnimeRepository) Upsert(ctx context.Context, animes []models.Anime) (int, error) {
+	if len(animes) == 0 {
+		return 0, nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(animes))
+	for _, anime := range animes {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"id": anime.ID}).
+			SetReplacement(newMongoAnime(anime)).
+			SetUpsert(true))
+	}
+
+	res, err := m.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, fmt.Errorf("bulk write error: %w", err)
+	}
+	return int(res.UpsertedCount + res.MatchedCount), nil
+}
+
+func (m *MongoAnimeRepository) VectorSearch(ctx context.Context, query VectorQuery) ([]models.ScoredAnime, error) {
+	pipeline := m.vectorSearchPipeline(query)
+
+	cursor, err := m.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("mongo aggregate error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.ScoredAnime
+	for cursor.Next(ctx) {
+		var doc struct {
+			models.Anime `bson:",inline"`
+			Score        float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			log.Println("decode error:", err)
+			continue
+		}
+		// Atlas reports cosine scores as (1 + cosine) / 2; convert back so
+		// scores match the other backends.
+		results = append(results, models.ScoredAnime{Anime: doc.Anime, Score: doc.Score*2 - 1})
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return results, nil
+}
+
+// vectorSearchPipeline builds the aggregation for VectorSearch. When the
+// index declares the embedding model (and, for versioned models, the
+// version) as filter fields, they are part of the pre-filter, so documents
+// of another model do not take up slots of the limit. An unversioned model
+// never stamps a version, so matching its ID is enough. Otherwise every
+// candidate is retrieved and the model is matched afterwards, keeping
+// unstamped legacy documents, whose dimension the Atlas index already
+// guarantees.
+func (m *MongoAnimeRepository) vectorSearchPipeline(query VectorQuery) mongo.Pipeline {
+	numCandidates := max(query.NumCandidates, query.Limit, 100)
+	preFilter := query.Model != "" && m.modelFilter && (query.Version == "" || m.versionFilter)
+	postFilter := query.Model != "" && !preFilter
+
+	limit := query.Limit
+	if postFilter {
+		limit = numCandidates
+	}
+	vectorSearch := bson.D{
+		{Key: "index", Value: m.vectorIndex},
+		{Key: "path", Value: "embedding"},
+		{Key: "queryVector", Value: query.Vector},
+		{Key: "numCandidates", Value: numCandidates},
+		{Key: "limit", Value: limit},
+	}
+
+	var clauses []bson.M
+	if preFilter {
+		clauses = append(clauses, bson.M{"embeddingInfo.model": bson.M{"$eq": query.Model}})
+		if query.Version != "" {
+			clauses = append(clauses, bson.M{"embeddingInfo.version": bson.M{"$eq": query.Version}})
+		}
+	}
+	if filter := mongoFilter(query.Filter); filter != nil {
+		clauses = append(clauses, filter)
+	}
+	switch len(clauses) {
+	case 0:
+	case 1:
+		vectorSearch = append(vectorSearch, bson.E{Key: "filter", Value: clauses[0]})
+	default:
+		vectorSearch = append(vectorSearch, bson.E{Key: "filter", Value: bson.M{"$and": clauses}})
+	}
+	pipeline := mongo.Pipeline{{{Key: "$vectorSearch", Value: vectorSearch}}}
+
+	if postFilter {
+		// Null matches a missing field, which is how an empty version is
+		// stored.
+		var version any = query.Version
+		if query.Version == "" {
+			version = bson.M{"$in": bson.A{nil, ""}}
+		}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$match", Value: bson.M{"$or": []bson.M{
+				{"embeddingInfo.model": query.Model, "embeddingInfo.version": version},
+				{"embeddingInfo.model": bson.M{"$exists": false}},
+			}}}},
+			bson.D{{Key: "$limit", Value: query.Limit}},
+		)
+	}
+
+	scoreStage := bson.D{
+		{Key: "$addFields", Value: bson.D{
+			{Key: "score", Value: bson.D{{Key: "$meta", Value: "vectorSearchScore"}}},
+		}}}
+
+	projectStage := bson.D{
+		{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+		}}}
+
+	return append(pipeline, scoreStage, projectStage)
+}
+
+func (m *MongoAnimeRepository) TextSearch(ctx context.Context, query TextQuery) ([]models.ScoredAnime, error) {
+	filter := bson.M{"$text": bson.M{"$search": query.Text}}
+	if f := mongoFilter(query.Filter); f != nil {
+		filter = bson.M{"$and": []bson.M{filter, f}}
+	}
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0, "score": score}).
+		SetSort(bson.M{"score": score}).
+		SetLimit(int64(query.Limit))
+
+	cursor, err := m.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongo find error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.ScoredAnime
+	for cursor.Next(ctx) {
+		var doc struct {
+			models.Anime `bson:",inline"`
+			Score        float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			log.Println("decode error:", err)
+			continue
+		}
+		results = append(results, models.ScoredAnime{Anime: doc.Anime, Score: doc.Score})
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+	return results, nil
+}
+
+func (m *MongoAnimeRepository) EmbeddingModelCounts(ctx context.Context) ([]models.EmbeddingModelCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "model", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$embeddingInfo.model", ""}}}},
+				{Key: "dimensions", Value: bson.D{{Key: "$size", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$embedding", bson.A{}}}}}}},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "model", Value: "$_id.model"},
+			{Key: "dimensions", Value: "$_id.dimensions"},
+			{Key: "count", Value: 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}}}},
+	}
+
+	cursor, err := m.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("mongo aggregate error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var report []models.EmbeddingModelCount
+	if err := cursor.All(ctx, &report); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+	return report, nil
+}
+
+func (m *MongoAnimeRepository) Facets(ctx context.Context, filter models.AnimeFilter) (models.Facets, error) {
+	facet := func(field string, array bool) bson.A {
+		stages := bson.A{}
+		if array {
+			stages = append(stages, bson.D{{Key: "$unwind", Value: "$" + field}})
+		}
+		return append(stages,
+			bson.D{{Key: "$match", Value: bson.M{field: bson.M{"$nin": bson.A{nil, ""}}}}},
+			bson.D{{Key: "$sortByCount", Value: "$" + field}},
+		)
+	}
+
+	pipeline := mongo.Pipeline{}
+	if match := mongoFilter(filter); match != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: bson.D{
+		{Key: "genres", Value: facet("genres", true)},
+		{Key: "status", Value: facet("status", false)},
+		{Key: "season", Value: facet("season", false)},
+		{Key: "source", Value: facet("source", false)},
+		{Key: "studios", Value: facet("studios", true)},
+	}}})
+
+	cursor, err := m.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.Facets{}, fmt.Errorf("mongo aggregate error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var facets models.Facets
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&facets); err != nil {
+			return models.Facets{}, fmt.Errorf("decode error: %w", err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return models.Facets{}, fmt.Errorf("cursor error: %w", err)
+	}
+	sortFacets(&facets)
+	return facets, nil
+}
+
+func (m *MongoAnimeRepository) StudioStats(ctx context.Context) ([]models.StudioStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$studios"}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$studios"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			// Unscored animes have no averageScore field, which $avg skips.
+			{Key: "averageScore", Value: bson.D{{Key: "$avg", Value: "$averageScore"}}},
+		}}},
+	}
+
+	cursor, err := m.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("mongo aggregate error: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	stats := []models.StudioStats{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+	sortStudioStats(stats)
+	return stats, nil
+}
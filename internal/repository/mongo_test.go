@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestVectorSearchPipeline(t *testing.T) {
+	tests := []struct {
+		name          string
+		modelFilter   bool
+		versionFilter bool
+		query         VectorQuery
+		wantPre       []string
+		wantNotPre    []string
+		wantLimit     int
+		wantStages    []string
+		wantPostStep  bool
+	}{
+		{
+			name:        "model declared as filter field",
+			modelFilter: true,
+			query:       VectorQuery{Model: "hash", Limit: 10, Filter: models.AnimeFilter{Genres: []string{"Action"}}},
+			wantPre:     []string{`"embeddingInfo.model":{"$eq":"hash"}`, `"genreKeys":{"$eq":"action"}`},
+			wantNotPre:  []string{"$exists"},
+			wantLimit:   10,
+			wantStages:  []string{"$vectorSearch", "$addFields", "$project"},
+		},
+		{
+			name:         "model matched after retrieval",
+			query:        VectorQuery{Model: "hash", Limit: 10, NumCandidates: 200, Filter: models.AnimeFilter{Genres: []string{"Action"}}},
+			wantPre:      []string{`"genreKeys":{"$eq":"action"}`},
+			wantNotPre:   []string{"embeddingInfo", "$exists"},
+			wantLimit:    200,
+			wantStages:   []string{"$vectorSearch", "$match", "$limit", "$addFields", "$project"},
+			wantPostStep: true,
+		},
+		{
+			name:          "versioned model declared as filter fields",
+			modelFilter:   true,
+			versionFilter: true,
+			query:         VectorQuery{Model: "hash", Version: "2", Limit: 10},
+			wantPre:       []string{`"embeddingInfo.model":{"$eq":"hash"}`, `"embeddingInfo.version":{"$eq":"2"}`},
+			wantLimit:     10,
+			wantStages:    []string{"$vectorSearch", "$addFields", "$project"},
+		},
+		{
+			name:         "version not declared",
+			modelFilter:  true,
+			query:        VectorQuery{Model: "hash", Version: "2", Limit: 10},
+			wantNotPre:   []string{"embeddingInfo"},
+			wantLimit:    100,
+			wantStages:   []string{"$vectorSearch", "$match", "$limit", "$addFields", "$project"},
+			wantPostStep: true,
+		},
+		{
+			name:       "no model",
+			query:      VectorQuery{Limit: 10},
+			wantNotPre: []string{"filter"},
+			wantLimit:  10,
+			wantStages: []string{"$vectorSearch", "$addFields", "$project"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MongoAnimeRepository{vectorIndex: "vector_index", modelFilter: tt.modelFilter, versionFilter: tt.versionFilter}
+			pipeline := m.vectorSearchPipeline(tt.query)
+
+			var stages []string
+			for _, stage := range pipeline {
+				stages = append(stages, stage[0].Key)
+			}
+			if strings.Join(stages, ",") != strings.Join(tt.wantStages, ",") {
+				t.Fatalf("stages = %v, want %v", stages, tt.wantStages)
+			}
+
+			search := pipeline[0][0].Value.(bson.D)
+			raw, err := bson.MarshalExtJSON(search, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.wantPre {
+				if !strings.Contains(string(raw), want) {
+					t.Errorf("$vectorSearch %s does not contain %s", raw, want)
+				}
+			}
+			for _, unwanted := range tt.wantNotPre {
+				if strings.Contains(string(raw), unwanted) {
+					t.Errorf("$vectorSearch %s contains %s", raw, unwanted)
+				}
+			}
+			for _, e := range search {
+				if e.Key == "limit" && e.Value != tt.wantLimit {
+					t.Errorf("$vectorSearch limit = %v, want %d", e.Value, tt.wantLimit)
+				}
+			}
+			if tt.wantPostStep {
+				if limit := pipeline[2][0].Value; limit != tt.query.Limit {
+					t.Errorf("$limit = %v, want %d", limit, tt.query.Limit)
+				}
+			}
+		})
+	}
+}
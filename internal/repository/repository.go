@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"context"
+	"errors"
+	"sort"
+)
+
+var ErrNotFound = errors.New("anime not found")
+
+type VectorQuery struct {
+	Vector []float32
+	// Model and Version restrict the search to documents embedded with this
+	// model ID and version.
+	Model   string
+	Version string
+	Limit   int
+	// NumCandidates is how many nearest neighbours are considered before
+	// the top Limit are returned. Zero lets the backend pick.
+	NumCandidates int
+	Filter        models.AnimeFilter
+}
+
+type TextQuery struct {
+	Text   string
+	Limit  int
+	Filter models.AnimeFilter
+}
+
+const (
+	SortScore    = "score"
+	SortYear     = "year"
+	SortTitle    = "title"
+	SortEpisodes = "episodes"
+)
+
+// sortFields maps list sort keys to document fields.
+var sortFields = map[string]string{
+	SortScore:    "averageScore",
+	SortYear:     "seasonYear",
+	SortTitle:    "title.romaji",
+	SortEpisodes: "episodes",
+}
+
+func ValidSort(sort string) bool {
+	_, ok := sortFields[sort]
+	return sort == "" || ok
+}
+
+// ListQuery selects one page of animes for browsing.
+type ListQuery struct {
+	Filter models.AnimeFilter
+	// Sort is one of the Sort constants, or empty to order by ID. Ties are
+	// always broken by ascending ID so pages are stable.
+	Sort       string
+	Descending bool
+	Offset     int
+	Limit      int
+	// IncludeEmbedding keeps the embedding vectors, which are left out by
+	// default because they dominate the document size.
+	IncludeEmbedding bool
+}
+
+type AnimeRepository interface {
+	List(ctx context.Context) ([]models.Anime, error)
+	// ListPage returns the requested page of animes matching the query's
+	// filter together with the total number of matches.
+	ListPage(ctx context.Context, query ListQuery) ([]models.Anime, int, error)
+	// Scan returns up to limit animes with an ID greater than afterID,
+	// ordered by ID, for jobs that walk the whole collection.
+	Scan(ctx context.Context, afterID int, limit int) ([]models.Anime, error)
+	GetByID(ctx context.Context, id int) (models.Anime, error)
+	// GetByIDs returns the animes with the given IDs that exist, in no
+	// particular order. Embeddings are left out unless includeEmbedding.
+	GetByIDs(ctx context.Context, ids []int, includeEmbedding bool) ([]models.Anime, error)
+	GetByName(ctx context.Context, name string) (models.Anime, error)
+	Random(ctx context.Context) (models.Anime, error)
+	TopRated(ctx context.Context, limit int64) ([]models.Anime, error)
+	Upsert(ctx context.Context, animes []models.Anime) (int, error)
+	VectorSearch(ctx context.Context, query VectorQuery) ([]models.ScoredAnime, error)
+	// TextSearch ranks animes by keyword relevance over titles, description,
+	// genres and studios.
+	TextSearch(ctx context.Context, query TextQuery) ([]models.ScoredAnime, error)
+	EmbeddingModelCounts(ctx context.Context) ([]models.EmbeddingModelCount, error)
+	// Facets counts the animes matching filter per genre, status, season,
+	// source and studio.
+	Facets(ctx context.Context, filter models.AnimeFilter) (models.Facets, error)
+	// StudioStats lists every studio with its anime count and mean score,
+	// most prolific first.
+	StudioStats(ctx context.Context) ([]models.StudioStats, error)
+}
+
+// sortFacets orders facet counts by descending count, then by value, so
+// both backends return ties in the same order. Empty facets become empty
+// lists rather than nil.
+func sortFacets(f *models.Facets) {
+	for _, counts := range []*[]models.FacetCount{&f.Genres, &f.Statuses, &f.Seasons, &f.Sources, &f.Studios} {
+		if *counts == nil {
+			*counts = []models.FacetCount{}
+		}
+		sort.Slice(*counts, func(i, j int) bool {
+			a, b := (*counts)[i], (*counts)[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Value < b.Value
+		})
+	}
+}
+
+func sortStudioStats(stats []models.StudioStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Name < stats[j].Name
+	})
+}
+
+// EmbeddingCompatible reports whether the stored embedding of anime can be
+// compared with a vector of the given model, version and dimension.
+// Documents written before embeddings were stamped are accepted when their
+// dimension matches.
+func EmbeddingCompatible(anime models.Anime, model, version string, dimensions int) bool {
+	if len(anime.Embedding) == 0 || len(anime.Embedding) != dimensions {
+		return false
+	}
+	if anime.EmbeddingInfo == nil || model == "" {
+		return true
+	}
+	return SameEmbeddingModel(anime.EmbeddingInfo, model, version)
+}
+
+// SameEmbeddingModel reports whether info stamps a vector of model at
+// version. Vectors from different versions of one model are not comparable.
+func SameEmbeddingModel(info *models.EmbeddingInfo, model, version string) bool {
+	return info != nil && info.Model == model && info.Version == version
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"anime/internal/models"
+	"testing"
+)
+
+func TestEmbeddingCompatible(t *testing.T) {
+	stamped := func(model, version string) models.Anime {
+		return models.Anime{
+			Embedding:     []float32{1, 0, 0},
+			EmbeddingInfo: &models.EmbeddingInfo{Model: model, Version: version, Dimensions: 3},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		anime    models.Anime
+		model    string
+		version  string
+		dims     int
+		want     bool
+		wantSame bool
+	}{
+		{"same model and version", stamped("hash", "2"), "hash", "2", 3, true, true},
+		{"older version", stamped("hash", "1"), "hash", "2", 3, false, false},
+		{"unversioned model", stamped("gemini", ""), "gemini", "", 3, true, true},
+		{"other model", stamped("gemini", ""), "hash", "2", 3, false, false},
+		{"other dimension", stamped("hash", "2"), "hash", "2", 4, false, true},
+		{"unstamped legacy document", models.Anime{Embedding: []float32{1, 0, 0}}, "hash", "2", 3, true, false},
+		{"no embedding", models.Anime{}, "hash", "2", 3, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EmbeddingCompatible(tt.anime, tt.model, tt.version, tt.dims); got != tt.want {
+				t.Errorf("EmbeddingCompatible = %v, want %v", got, tt.want)
+			}
+			if got := SameEmbeddingModel(tt.anime.EmbeddingInfo, tt.model, tt.version); got != tt.wantSame {
+				t.Errorf("SameEmbeddingModel = %v, want %v", got, tt.wantSame)
+			}
+		})
+	}
+}
@@ -1,143 +1,90 @@
 package service
 
 import (
-	"anime/internal/database"
 	"anime/internal/embeddings"
 	"anime/internal/models"
+	"anime/internal/repository"
+	"anime/internal/titleindex"
 	"anime/internal/utils"
 	"context"
-	"fmt"
 	"log"
-	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 )
 
-func InsertAnimes(page int64, perPage int64) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+type AnimeService struct {
+	repo        repository.AnimeRepository
+	embedder    embeddings.Embedder
+	checkpoints repository.CheckpointStore
+	anilist     *utils.AniListClient
 
-	animes, err := utils.GraphQLAPIRequest(page, perPage)
-	if err != nil {
-		return 0, err
-	}
+	reembedMu sync.Mutex
+	reembed   models.ReembedStatus
 
-	var animeDocs []any
+	syncMu sync.Mutex
+	sync   models.SyncStatus
 
-	for _, animeResp := range animes {
-		embeddingText := animeResp.Title.Romaji + " " + animeResp.Title.English + " " + animeResp.Description + " " + strings.Join(animeResp.Genres, " ")
+	rankingMu sync.RWMutex
+	ranking   RankingWeights
 
-		embedding, err := embeddings.GenerateEmbedding(embeddingText)
-		if err != nil {
-			log.Println("embedding error:", err)
-			continue
-		}
+	pipeline PipelineOptions
 
-		anime := utils.ConvertResponseToAnime(animeResp, embedding)
-
-		animeDocs = append(animeDocs, anime)
-	}
+	titles    *titleindex.Index
+	suggestMu sync.Mutex
+	titleDocs map[int]titleindex.Doc
+	suggester atomic.Pointer[titleindex.Suggester]
+}
 
-	if len(animeDocs) > 0 {
-		_, err = database.NewAnimeCollection.InsertMany(ctx, animeDocs)
-		if err != nil {
-			return 0, fmt.Errorf("insert many error: %w", err)
-		}
-		log.Printf("Successfully inserted %d anime entries\n", len(animeDocs))
-	} else {
-		log.Println("No valid anime to insert")
+func NewAnimeService(repo repository.AnimeRepository, embedder embeddings.Embedder, checkpoints repository.CheckpointStore, anilist *utils.AniListClient) *AnimeService {
+	return &AnimeService{
+		repo:        repo,
+		embedder:    embedder,
+		checkpoints: checkpoints,
+		anilist:     anilist,
+		ranking:     DefaultRankingWeights(),
+		pipeline:    DefaultPipelineOptions(),
+		titles:      titleindex.New(),
+		titleDocs:   make(map[int]titleindex.Doc),
 	}
-	return len(animeDocs), nil
 }
 
-func InsertAnimesConcurrent(startPage int64, endPage int64, perPage int64) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+// EmbedQuery embeds free-text queries with the same provider used for
+// ingestion, so query vectors are always comparable to stored ones.
+func (s *AnimeService) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	return s.embedder.Embed(ctx, query)
+}
 
-	var (
-		wg            sync.WaitGroup
-		mu            sync.Mutex
-		allDocs       []any
-		totalInserted int
-	)
+func (s *AnimeService) EmbeddingModel() string {
+	return s.embedder.ModelID()
+}
 
-	type result struct {
-		docs []any
-		err  error
+func (s *AnimeService) InsertAnimes(ctx context.Context, page int64, perPage int64) (models.IngestReport, error) {
+	animes, err := s.anilist.GraphQLAPIRequest(ctx, page, perPage)
+	if err != nil {
+		return models.IngestReport{}, err
 	}
 
-	resultsChan := make(chan result)
-
-	for page := startPage; page <= endPage; page++ {
-		wg.Add(1)
-
-		go func(p int64) {
-			defer wg.Done()
-
-			animes, err := utils.GraphQLAPIRequest(p, perPage)
-			if err != nil {
-				log.Printf("error fetching page %d: %v", p, err)
-				resultsChan <- result{nil, err}
-				return
-			}
-
-			var innerWg sync.WaitGroup
-			var docs []any
-			var innerMu sync.Mutex
-
-			for _, animeResp := range animes {
-				innerWg.Add(1)
-
-				go func(ar models.AnimeResponse) {
-					defer innerWg.Done()
-
-					embeddingText := ar.Title.Romaji + " " + ar.Title.English + " " + ar.Description + " " + strings.Join(ar.Genres, " ")
-
-					embedding, err := embeddings.GenerateEmbeddingsOllama(embeddingText)
-					if err != nil {
-						log.Println("embedding error:", err)
-						return
-					}
-
-					doc := utils.ConvertResponseToAnime(ar, embedding)
-
-					innerMu.Lock()
-					docs = append(docs, doc)
-					innerMu.Unlock()
-				}(animeResp)
-			}
-
-			innerWg.Wait()
-			resultsChan <- result{docs, nil}
-		}(page)
+	report, err := s.ingest(ctx, animes)
+	if err != nil {
+		return report, err
 	}
+	s.rebuildSuggestions()
 
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
-
-	for res := range resultsChan {
-		if res.err != nil {
-			continue
-		}
-
-		mu.Lock()
-		allDocs = append(allDocs, res.docs...)
-		totalInserted += len(res.docs)
-		mu.Unlock()
-	}
+	log.Printf("Ingested page %d: %d inserted, %d updated, %d unchanged, %d failed\n",
+		page, report.Inserted, report.Updated, report.Unchanged, report.Failed)
+	return report, nil
+}
 
-	if len(allDocs) > 0 {
-		_, err := database.NewAnimeCollection.InsertMany(ctx, allDocs)
-		if err != nil {
-			return 0, fmt.Errorf("insert many error: %w", err)
-		}
-		log.Printf("Successfully inserted %d anime entries\n", totalInserted)
-	} else {
-		log.Println("No valid anime to insert")
+// InsertAnimesConcurrent ingests pages startPage..endPage through the
+// bounded pipeline. Unset fields of opts fall back to the service defaults.
+func (s *AnimeService) InsertAnimesConcurrent(ctx context.Context, startPage int64, endPage int64, perPage int64, opts PipelineOptions) (models.IngestReport, error) {
+	report, err := s.runPipeline(ctx, startPage, endPage, perPage, opts.withDefaults(s.pipeline))
+	s.rebuildSuggestions()
+	if err != nil {
+		return report, err
 	}
 
-	return totalInserted, nil
-
+	log.Printf("Ingested pages %d-%d: %d inserted, %d updated, %d unchanged, %d failed, %d pages failed\n",
+		startPage, endPage, report.Inserted, report.Updated, report.Unchanged, report.Failed, len(report.FailedPages))
+	return report, nil
 }
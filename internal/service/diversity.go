@@ -0,0 +1,95 @@
+package service
+
+import (
+	"anime/internal/models"
+	"math"
+	"strings"
+)
+
+const (
+	// mmrPoolFactor is how many pages of extra candidates beyond the
+	// requested window are fetched so the re-ranking has alternatives to
+	// choose from. maxMMRPoolExtra caps that extra whatever the page size.
+	mmrPoolFactor   = 3
+	maxMMRPoolExtra = 300
+)
+
+// diversify selects n results from candidates with maximal marginal
+// relevance: each pick maximises
+//
+//	(1 - diversity) * relevance - diversity * max similarity to earlier picks
+//
+// MaxPerGenre and MaxPerStudio cap how many picks may share a genre or
+// studio. The caps are soft: when they leave the list short, the best
+// remaining candidates are appended in MMR order.
+func diversify(candidates []models.ScoredAnime, n int, opts RecommendOptions) []models.ScoredAnime {
+	lambda := 1 - opts.Diversity
+	selected := make([]models.ScoredAnime, 0, n)
+	var deferred []models.ScoredAnime
+	genres := make(map[string]int)
+	studios := make(map[string]int)
+
+	// redundancy[i] is candidate i's max similarity to the picks so far,
+	// updated against each new pick only, so every round is linear in the
+	// pool.
+	redundancy := make([]float64, len(candidates))
+	used := make([]bool, len(candidates))
+	for left := len(candidates); len(selected) < n && left > 0; left-- {
+		best, bestScore := -1, math.Inf(-1)
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+			score := lambda*candidate.Score - (1-lambda)*redundancy[i]
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		used[best] = true
+		pick := candidates[best]
+
+		if exceedsCap(pick.Anime.Genres, genres, opts.MaxPerGenre) || exceedsCap(pick.Anime.Studios, studios, opts.MaxPerStudio) {
+			deferred = append(deferred, pick)
+			continue
+		}
+
+		selected = append(selected, pick)
+		countValues(pick.Anime.Genres, genres)
+		countValues(pick.Anime.Studios, studios)
+
+		if lambda < 1 {
+			for i, candidate := range candidates {
+				if !used[i] {
+					redundancy[i] = max(redundancy[i], similarity(candidate.Anime.Embedding, pick.Anime.Embedding))
+				}
+			}
+		}
+	}
+
+	for _, pick := range deferred {
+		if len(selected) == n {
+			break
+		}
+		selected = append(selected, pick)
+	}
+	return selected
+}
+
+func exceedsCap(values []string, counts map[string]int, limit int) bool {
+	if limit <= 0 {
+		return false
+	}
+	for _, v := range values {
+		if counts[strings.ToLower(v)] >= limit {
+			return true
+		}
+	}
+	return false
+}
+
+func countValues(values []string, counts map[string]int) {
+	for _, v := range values {
+		counts[strings.ToLower(v)]++
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"anime/internal/models"
+	"slices"
+	"testing"
+)
+
+func scored(id int, score float64, embedding []float32, genre, studio string) models.ScoredAnime {
+	return models.ScoredAnime{
+		Anime: models.Anime{ID: id, Embedding: embedding, Genres: []string{genre}, Studios: []string{studio}},
+		Score: score,
+	}
+}
+
+func TestDiversify(t *testing.T) {
+	// 2 is a near duplicate of 1; 3 and 4 are unlike anything else.
+	candidates := []models.ScoredAnime{
+		scored(1, 0.95, []float32{1, 0, 0}, "Action", "Bones"),
+		scored(2, 0.94, []float32{0.99, 0.1, 0}, "action", "Bones"),
+		scored(3, 0.80, []float32{0, 1, 0}, "Comedy", "Shaft"),
+		scored(4, 0.70, []float32{0, 0, 1}, "Drama", "Madhouse"),
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		opts RecommendOptions
+		want []int
+	}{
+		{"no diversity keeps similarity order", 3, RecommendOptions{}, []int{1, 2, 3}},
+		{"diversity skips near duplicates", 3, RecommendOptions{Diversity: 0.5}, []int{1, 3, 4}},
+		{"full diversity still starts with the best", 2, RecommendOptions{Diversity: 1}, []int{1, 3}},
+		{"genre cap ignores case", 3, RecommendOptions{MaxPerGenre: 1}, []int{1, 3, 4}},
+		{"studio cap", 3, RecommendOptions{MaxPerStudio: 1}, []int{1, 3, 4}},
+		{"caps are soft", 4, RecommendOptions{MaxPerGenre: 1}, []int{1, 3, 4, 2}},
+		{"n beyond pool", 10, RecommendOptions{Diversity: 0.3}, []int{1, 3, 4, 2}},
+		{"n zero", 0, RecommendOptions{Diversity: 0.5}, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := []int{}
+			for _, pick := range diversify(candidates, tt.n, tt.opts) {
+				ids = append(ids, pick.Anime.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("diversify = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"anime/internal/models"
+	"anime/internal/textindex"
+	"strings"
+)
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "anime": true, "about": true, "are": true,
+	"for": true, "from": true, "in": true, "into": true, "is": true, "like": true,
+	"of": true, "on": true, "or": true, "show": true, "some": true, "that": true,
+	"the": true, "this": true, "to": true, "with": true,
+}
+
+// explainer holds what results are explained against: the seed animes and
+// the free-text query, either of which may be empty.
+type explainer struct {
+	seeds      []models.Anime
+	queryTerms map[string]bool
+}
+
+func newExplainer(seeds []models.Anime, query string) explainer {
+	e := explainer{seeds: seeds, queryTerms: make(map[string]bool)}
+	for _, term := range textindex.Tokenize(query) {
+		if !stopwords[term] {
+			e.queryTerms[term] = true
+		}
+	}
+	return e
+}
+
+// explain attaches an explanation to each result.
+func (e explainer) explain(results []models.ScoredAnime) {
+	for i := range results {
+		results[i].Explanation = e.explanation(results[i])
+	}
+}
+
+func (e explainer) explanation(result models.ScoredAnime) *models.Explanation {
+	anime := result.Anime
+	x := &models.Explanation{
+		SharedGenres:  e.shared(anime.Genres, func(seed models.Anime) []string { return seed.Genres }),
+		SharedStudios: e.shared(anime.Studios, func(seed models.Anime) []string { return seed.Studios }),
+		Components:    result.Components,
+	}
+
+	for _, seed := range e.seeds {
+		score := similarity(anime.Embedding, seed.Embedding)
+		if x.MostSimilarSeed == nil || score > x.MostSimilarSeed.Similarity {
+			x.MostSimilarSeed = &models.SeedSimilarity{ID: seed.ID, Title: seed.Title, Similarity: score}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, term := range textindex.Tokenize(anime.Description) {
+		if e.queryTerms[term] && !seen[term] {
+			seen[term] = true
+			x.MatchedKeywords = append(x.MatchedKeywords, term)
+		}
+	}
+	return x
+}
+
+// shared returns the values of the candidate that also appear on a seed or
+// whose words all occur in the query.
+func (e explainer) shared(values []string, of func(models.Anime) []string) []string {
+	fromSeeds := make(map[string]bool)
+	for _, seed := range e.seeds {
+		for _, v := range of(seed) {
+			fromSeeds[strings.ToLower(v)] = true
+		}
+	}
+
+	shared := []string{}
+	for _, v := range values {
+		if fromSeeds[strings.ToLower(v)] || e.inQuery(v) {
+			shared = append(shared, v)
+		}
+	}
+	return shared
+}
+
+func (e explainer) inQuery(value string) bool {
+	matched := false
+	for _, term := range textindex.Tokenize(value) {
+		if stopwords[term] {
+			continue
+		}
+		if !e.queryTerms[term] {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"anime/internal/models"
+	"anime/internal/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ingest upserts one page of AniList results keyed by ID. Animes whose
+// embedding text is unchanged keep their stored embedding, and animes that
+// are identical to the stored document are not written at all.
+func (s *AnimeService) ingest(ctx context.Context, responses []models.AnimeResponse) (models.IngestReport, error) {
+	page, err := s.prepareIngest(ctx, responses)
+	if err != nil {
+		return page.report, err
+	}
+	err = s.storeIngest(ctx, &page, 0, s.pipeline.WriteTimeout)
+	return page.report, err
+}
+
+// ingestPage holds the documents of one page that need writing, once their
+// embeddings are ready.
+type ingestPage struct {
+	writes  []models.Anime
+	existed map[int]bool
+	report  models.IngestReport
+}
+
+// prepareIngest converts responses, reuses or generates embeddings and
+// drops documents identical to the stored ones.
+func (s *AnimeService) prepareIngest(ctx context.Context, responses []models.AnimeResponse) (ingestPage, error) {
+	page := ingestPage{existed: make(map[int]bool)}
+	if len(responses) == 0 {
+		return page, nil
+	}
+
+	ids := make([]int, 0, len(responses))
+	for _, resp := range responses {
+		ids = append(ids, resp.ID)
+	}
+	stored, err := s.repo.GetByIDs(ctx, ids, true)
+	if err != nil {
+		return page, fmt.Errorf("lookup error: %w", err)
+	}
+	existing := make(map[int]models.Anime, len(stored))
+	for _, anime := range stored {
+		existing[anime.ID] = anime
+	}
+
+	var docs, stale []models.Anime
+	for _, resp := range responses {
+		doc := utils.ConvertResponseToAnime(resp, nil)
+		doc.ContentHash = utils.ContentHash(utils.EmbeddingText(doc.Title, doc.Description, doc.Genres))
+
+		old, ok := existing[doc.ID]
+		if ok && old.ContentHash == doc.ContentHash && s.embeddedWithActiveModel(old) {
+			doc.Embedding, doc.EmbeddingInfo = old.Embedding, old.EmbeddingInfo
+			docs = append(docs, doc)
+		} else {
+			stale = append(stale, doc)
+		}
+	}
+
+	if len(stale) > 0 {
+		embedded, err := s.embedDocuments(ctx, stale)
+		if err != nil {
+			return page, err
+		}
+		page.report.Failed += len(stale) - len(embedded)
+		page.report.Embedded += len(embedded)
+		docs = append(docs, embedded...)
+	}
+
+	for _, doc := range docs {
+		old, ok := existing[doc.ID]
+		if ok && sameDocument(old, doc) {
+			page.report.Unchanged++
+			continue
+		}
+		page.existed[doc.ID] = ok
+		page.writes = append(page.writes, doc)
+	}
+	return page, nil
+}
+
+// storeIngest upserts the prepared documents in batches of batchSize, or all
+// at once when batchSize is 0, giving each upsert timeout, and counts what
+// was written.
+func (s *AnimeService) storeIngest(ctx context.Context, page *ingestPage, batchSize int, timeout time.Duration) error {
+	if batchSize <= 0 {
+		batchSize = len(page.writes)
+	}
+	for start := 0; start < len(page.writes); start += batchSize {
+		batch := page.writes[start:min(start+batchSize, len(page.writes))]
+		writeCtx, cancel := context.WithTimeout(ctx, timeout)
+		_, err := s.repo.Upsert(writeCtx, batch)
+		cancel()
+		if err != nil {
+			page.report.Failed += len(page.writes) - start
+			return fmt.Errorf("upsert error: %w", err)
+		}
+		for _, doc := range batch {
+			if page.existed[doc.ID] {
+				page.report.Updated++
+			} else {
+				page.report.Inserted++
+			}
+		}
+		s.indexTitles(batch)
+	}
+	return nil
+}
+
+// sameDocument compares the JSON forms of two animes, which treats nil and
+// empty lists alike the way a MongoDB round trip does.
+func sameDocument(a, b models.Anime) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"anime/internal/embeddings"
+	"anime/internal/models"
+	"anime/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// slowEmbedder delays every batch, like a remote provider would.
+type slowEmbedder struct {
+	*embeddings.HashEmbedder
+	delay time.Duration
+}
+
+func (e slowEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	select {
+	case <-time.After(e.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return e.HashEmbedder.EmbedBatch(ctx, texts)
+}
+
+// slowRepository delays every upsert until the write is cancelled or delay
+// has passed.
+type slowRepository struct {
+	*repository.MemoryAnimeRepository
+	delay time.Duration
+}
+
+func (r slowRepository) Upsert(ctx context.Context, animes []models.Anime) (int, error) {
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	return r.MemoryAnimeRepository.Upsert(ctx, animes)
+}
+
+func TestIngestWriteTimeout(t *testing.T) {
+	responses := make([]models.AnimeResponse, 3)
+	for i := range responses {
+		responses[i] = models.AnimeResponse{ID: i + 1, Title: models.Title{Romaji: fmt.Sprintf("Anime %d", i+1)}}
+	}
+
+	tests := []struct {
+		name         string
+		embedDelay   time.Duration
+		writeDelay   time.Duration
+		wantErr      error
+		wantInserted int
+		wantFailed   int
+	}{
+		{"slow embedding is not bounded", 100 * time.Millisecond, 0, nil, 3, 0},
+		{"slow write times out", 0, time.Second, context.DeadlineExceeded, 0, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := slowRepository{repository.NewMemoryAnimeRepository(), tt.writeDelay}
+			embedder := slowEmbedder{embeddings.NewHashEmbedder(64), tt.embedDelay}
+			s := NewAnimeService(repo, embedder, repository.NewMemoryCheckpointStore(), nil)
+			s.SetPipelineOptions(PipelineOptions{WriteTimeout: 50 * time.Millisecond})
+
+			report, err := s.ingest(context.Background(), responses)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if report.Inserted != tt.wantInserted || report.Failed != tt.wantFailed {
+				t.Errorf("inserted %d, failed %d; want %d, %d", report.Inserted, report.Failed, tt.wantInserted, tt.wantFailed)
+			}
+		})
+	}
+}
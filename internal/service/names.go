@@ -0,0 +1,116 @@
+package service
+
+import (
+	"anime/internal/models"
+	"anime/internal/repository"
+	"anime/internal/titleindex"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+const (
+	MaxNameCandidates = 20
+	MaxSuggestions    = 20
+
+	minNameConfidence = 0.4
+)
+
+// RebuildTitleIndex loads the titles of every stored anime into the fuzzy
+// name index.
+func (s *AnimeService) RebuildTitleIndex(ctx context.Context) error {
+	start := time.Now()
+	lastID := 0
+	for {
+		batch, err := s.repo.Scan(ctx, lastID, 500)
+		if err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		s.indexTitles(batch)
+		lastID = batch[len(batch)-1].ID
+	}
+
+	s.rebuildSuggestions()
+	log.Printf("Title index ready: %d animes in %s\n", s.titles.Len(), time.Since(start))
+	return nil
+}
+
+// indexTitles adds animes to the fuzzy name index. The suggestion index is
+// rebuilt separately by rebuildSuggestions once ingestion completes.
+func (s *AnimeService) indexTitles(animes []models.Anime) {
+	s.suggestMu.Lock()
+	defer s.suggestMu.Unlock()
+
+	for _, anime := range animes {
+		t := anime.Title
+		s.titles.Add(anime.ID, t.Romaji, t.English, t.DisplayRomaji, t.DisplayEnglish)
+
+		var titles []string
+		for _, title := range []string{t.Romaji, t.English, t.DisplayRomaji, t.DisplayEnglish} {
+			if title != "" && !slices.Contains(titles, title) {
+				titles = append(titles, title)
+			}
+		}
+		s.titleDocs[anime.ID] = titleindex.Doc{ID: anime.ID, Titles: titles, Popularity: anime.Popularity}
+	}
+}
+
+func (s *AnimeService) rebuildSuggestions() {
+	s.suggestMu.Lock()
+	docs := make([]titleindex.Doc, 0, len(s.titleDocs))
+	for _, doc := range s.titleDocs {
+		docs = append(docs, doc)
+	}
+	s.suggestMu.Unlock()
+
+	s.suggester.Store(titleindex.NewSuggester(docs))
+}
+
+// Suggest returns title completions for a partially typed name.
+func (s *AnimeService) Suggest(prefix string, limit int) []models.TitleSuggestion {
+	suggester := s.suggester.Load()
+	if suggester == nil {
+		return []models.TitleSuggestion{}
+	}
+
+	hits := suggester.Suggest(prefix, limit)
+	suggestions := make([]models.TitleSuggestion, 0, len(hits))
+	for _, hit := range hits {
+		suggestions = append(suggestions, models.TitleSuggestion{ID: hit.ID, Title: hit.Title, Popularity: hit.Popularity})
+	}
+	return suggestions
+}
+
+// FindByName ranks animes whose titles fuzzily match name. Matching ignores
+// case, diacritics, punctuation and long-vowel spelling. When the index has
+// no match, the repository's exact lookup is tried as a fallback.
+func (s *AnimeService) FindByName(ctx context.Context, name string, limit int) ([]models.NameMatch, error) {
+	matches := s.titles.Search(name, limit, minNameConfidence)
+
+	results := make([]models.NameMatch, 0, len(matches))
+	for _, match := range matches {
+		anime, err := s.repo.GetByID(ctx, match.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, models.NameMatch{Anime: anime, MatchedTitle: match.Title, Confidence: match.Confidence})
+	}
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	anime, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return []models.NameMatch{{Anime: anime, MatchedTitle: name, Confidence: 1}}, nil
+}
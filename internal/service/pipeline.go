@@ -0,0 +1,180 @@
+package service
+
+import (
+	"anime/internal/embeddings"
+	"anime/internal/models"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PipelineOptions bounds the concurrent ingestion pipeline. Fetching is
+// additionally limited by the shared AniList client, and each embed worker
+// embeds one page at a time, so EmbedConcurrency caps the requests in flight
+// to the embedding provider.
+type PipelineOptions struct {
+	FetchConcurrency int
+	EmbedConcurrency int
+	// InsertBatchSize is the most documents written in one upsert.
+	InsertBatchSize int
+	// WriteTimeout bounds each upsert. It does not cover embedding, which
+	// can take much longer with a remote provider.
+	WriteTimeout time.Duration
+}
+
+func DefaultPipelineOptions() PipelineOptions {
+	return PipelineOptions{FetchConcurrency: 2, EmbedConcurrency: 4, InsertBatchSize: 50, WriteTimeout: 10 * time.Second}
+}
+
+// withDefaults fills unset options from the service's configured ones.
+func (o PipelineOptions) withDefaults(defaults PipelineOptions) PipelineOptions {
+	if o.FetchConcurrency <= 0 {
+		o.FetchConcurrency = defaults.FetchConcurrency
+	}
+	if o.EmbedConcurrency <= 0 {
+		o.EmbedConcurrency = defaults.EmbedConcurrency
+	}
+	if o.InsertBatchSize <= 0 {
+		o.InsertBatchSize = defaults.InsertBatchSize
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = defaults.WriteTimeout
+	}
+	return o
+}
+
+func (s *AnimeService) PipelineOptions() PipelineOptions {
+	return s.pipeline
+}
+
+// SetPipelineOptions replaces the defaults used by InsertAnimesConcurrent.
+// It is meant to be called once at startup.
+func (s *AnimeService) SetPipelineOptions(opts PipelineOptions) {
+	s.pipeline = opts.withDefaults(DefaultPipelineOptions())
+}
+
+type fetchedPage struct {
+	page   int64
+	animes []models.AnimeResponse
+}
+
+type preparedPage struct {
+	page int64
+	ingestPage
+}
+
+// runPipeline ingests pages startPage..endPage through fetch workers, embed
+// workers and a single writer, connected by channels no larger than the
+// worker counts. A page is written as soon as its embeddings are ready.
+// Pages that fail to fetch or embed are skipped and listed in the report's
+// FailedPages; running out of embedding quota stops the whole run.
+func (s *AnimeService) runPipeline(ctx context.Context, startPage, endPage, perPage int64, opts PipelineOptions) (models.IngestReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		errMu       sync.Mutex
+		firstErr    error
+		failedPages []int64
+	)
+	failPage := func(page int64) {
+		errMu.Lock()
+		failedPages = append(failedPages, page)
+		errMu.Unlock()
+	}
+	fail := func(err error) {
+		errMu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		errMu.Unlock()
+		cancel()
+	}
+
+	pages := make(chan int64)
+	go func() {
+		defer close(pages)
+		for page := startPage; page <= endPage; page++ {
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	fetched := make(chan fetchedPage, opts.FetchConcurrency)
+	var fetchers sync.WaitGroup
+	for range opts.FetchConcurrency {
+		fetchers.Add(1)
+		go func() {
+			defer fetchers.Done()
+			for page := range pages {
+				animes, _, err := s.anilist.FetchAnimePage(ctx, models.AniListQuery{Page: page, PerPage: perPage})
+				if err != nil {
+					log.Printf("error fetching page %d: %v", page, err)
+					failPage(page)
+					continue
+				}
+				select {
+				case fetched <- fetchedPage{page: page, animes: animes}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		fetchers.Wait()
+		close(fetched)
+	}()
+
+	prepared := make(chan preparedPage, opts.EmbedConcurrency)
+	var embedders sync.WaitGroup
+	for range opts.EmbedConcurrency {
+		embedders.Add(1)
+		go func() {
+			defer embedders.Done()
+			for f := range fetched {
+				page, err := s.prepareIngest(ctx, f.animes)
+				if errors.Is(err, embeddings.ErrQuotaExceeded) {
+					fail(err)
+					return
+				}
+				if err != nil {
+					log.Printf("error preparing page %d: %v", f.page, err)
+					failPage(f.page)
+					continue
+				}
+				select {
+				case prepared <- preparedPage{page: f.page, ingestPage: page}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		embedders.Wait()
+		close(prepared)
+	}()
+
+	var report models.IngestReport
+	for p := range prepared {
+		if err := s.storeIngest(ctx, &p.ingestPage, opts.InsertBatchSize, opts.WriteTimeout); err != nil {
+			log.Printf("error storing page %d: %v", p.page, err)
+		}
+		report.Add(p.report)
+	}
+
+	slices.Sort(failedPages)
+	report.FailedPages = failedPages
+
+	if firstErr != nil {
+		return report, firstErr
+	}
+	return report, context.Cause(ctx)
+}
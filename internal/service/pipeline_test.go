@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestPipelineFailedPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		failPage  int64
+		opts      PipelineOptions
+		wantPages []int64
+	}{
+		{"all pages fetched", 0, PipelineOptions{}, nil},
+		{"one page fails", 2, PipelineOptions{}, []int64{2}},
+		{"one page fails with one worker each", 3, PipelineOptions{FetchConcurrency: 1, EmbedConcurrency: 1, InsertBatchSize: 1}, []int64{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeAniList{failPage: tt.failPage}
+			s, repo, _ := newSyncService(t, fake)
+
+			report, err := s.InsertAnimesConcurrent(context.Background(), 1, fakeLastPage, fakePerPage, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(report.FailedPages, tt.wantPages) {
+				t.Errorf("failed pages %v, want %v", report.FailedPages, tt.wantPages)
+			}
+			want := (fakeLastPage - len(tt.wantPages)) * fakePerPage
+			if report.Inserted != want {
+				t.Errorf("inserted %d, want %d", report.Inserted, want)
+			}
+			animes, _ := repo.List(context.Background())
+			for _, anime := range animes {
+				if slices.Contains(tt.wantPages, int64(anime.ID/10)) {
+					t.Errorf("stored anime %d from failed page %d", anime.ID, anime.ID/10)
+				}
+			}
+			if len(animes) != want {
+				t.Errorf("stored %d animes, want %d", len(animes), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"anime/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// popularityReference is roughly the AniList popularity of the most
+	// followed titles; popularity is log-scaled against it.
+	popularityReference = 1_000_000
+	recencyBaseYear     = 1960
+)
+
+// RankingWeights controls how the final recommendation score is blended
+// from cosine similarity and the quality, popularity and recency priors.
+// The blended score is the weighted mean of the components.
+type RankingWeights struct {
+	Similarity float64 `json:"similarity"`
+	Quality    float64 `json:"quality"`
+	Popularity float64 `json:"popularity"`
+	Recency    float64 `json:"recency"`
+}
+
+// DefaultRankingWeights ranks by similarity alone.
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{Similarity: 1}
+}
+
+func (w RankingWeights) similarityOnly() bool {
+	return w.Quality == 0 && w.Popularity == 0 && w.Recency == 0
+}
+
+func (w RankingWeights) override(o models.RankingWeights) RankingWeights {
+	w.Similarity = weightOr(o.Similarity, w.Similarity)
+	w.Quality = weightOr(o.Quality, w.Quality)
+	w.Popularity = weightOr(o.Popularity, w.Popularity)
+	w.Recency = weightOr(o.Recency, w.Recency)
+	return w
+}
+
+func (w RankingWeights) blend(c models.ScoreComponents) float64 {
+	total := w.Similarity + w.Quality + w.Popularity + w.Recency
+	if total == 0 {
+		return c.Similarity
+	}
+	return (w.Similarity*c.Similarity + w.Quality*c.Quality + w.Popularity*c.Popularity + w.Recency*c.Recency) / total
+}
+
+// RankingWeights returns the server-wide ranking weights.
+func (s *AnimeService) RankingWeights() RankingWeights {
+	s.rankingMu.RLock()
+	defer s.rankingMu.RUnlock()
+	return s.ranking
+}
+
+func (s *AnimeService) SetRankingWeights(w RankingWeights) {
+	s.rankingMu.Lock()
+	defer s.rankingMu.Unlock()
+	s.ranking = w
+}
+
+// rankingFor resolves the weights used for one request.
+func (s *AnimeService) rankingFor(opts RecommendOptions) RecommendOptions {
+	opts.ranking = s.RankingWeights().override(opts.Weights)
+	return opts
+}
+
+func scoreComponents(anime models.Anime, similarity float64, now time.Time) models.ScoreComponents {
+	c := models.ScoreComponents{Similarity: similarity}
+	if anime.AverageScore > 0 {
+		c.Quality = float64(anime.AverageScore) / 100
+	}
+	if anime.Popularity > 0 {
+		c.Popularity = min(1, math.Log1p(float64(anime.Popularity))/math.Log1p(popularityReference))
+	}
+	if anime.SeasonYear > 0 {
+		span := float64(now.Year() - recencyBaseYear)
+		c.Recency = min(1, max(0, float64(anime.SeasonYear-recencyBaseYear)/span))
+	}
+	return c
+}
+
+// blendScores replaces each similarity score with the blended score,
+// records the components and re-sorts the results.
+func blendScores(results []models.ScoredAnime, w RankingWeights) {
+	now := time.Now()
+	for i := range results {
+		c := scoreComponents(results[i].Anime, results[i].Score, now)
+		results[i].Components = &c
+		results[i].Score = w.blend(c)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"anime/internal/embeddings"
+	"anime/internal/models"
+	"anime/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	MaxRecommendLimit  = 50
+	MaxRecommendOffset = 500
+
+	candidatesPerResult = 10
+	maxNumCandidates    = 10000
+)
+
+var ErrSeedNotEmbedded = errors.New("seed anime has no embedding for the active model")
+
+type RecommendOptions struct {
+	Limit    int
+	Offset   int
+	MinScore float64
+	Filter   models.AnimeFilter
+	// Diversity in [0, 1] re-ranks results with maximal marginal relevance;
+	// 0 keeps pure similarity order.
+	Diversity    float64
+	MaxPerGenre  int
+	MaxPerStudio int
+	// Weights overrides the server's ranking weights for this request.
+	Weights models.RankingWeights
+	// Explain attaches an explanation to every result.
+	Explain bool
+
+	ranking RankingWeights
+}
+
+func (o RecommendOptions) diversified() bool {
+	return o.Diversity > 0 || o.MaxPerGenre > 0 || o.MaxPerStudio > 0
+}
+
+// reranked reports whether results may be reordered away from pure
+// similarity order, which needs a larger candidate pool.
+func (o RecommendOptions) reranked() bool {
+	return o.diversified() || !o.ranking.similarityOnly()
+}
+
+// fetchSize is how many ranked results to request so that, after seeds are
+// excluded and results are re-ranked, the requested page can be filled.
+func (o RecommendOptions) fetchSize(excluded int) int {
+	window := o.Offset + o.Limit + excluded
+	if o.reranked() {
+		window = min(window+min(o.Limit*mmrPoolFactor, maxMMRPoolExtra), maxNumCandidates)
+	}
+	return window
+}
+
+// Recommend embeds query and returns the page of nearest animes described by
+// opts, best match first.
+func (s *AnimeService) Recommend(ctx context.Context, query string, opts RecommendOptions) ([]models.ScoredAnime, error) {
+	vector, err := s.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.searchVector(ctx, vector, opts, nil)
+	if err == nil && opts.Explain {
+		newExplainer(nil, query).explain(results)
+	}
+	return results, err
+}
+
+// Similar recommends animes close to the stored embedding of the anime with
+// the given ID, excluding the seed itself. No embedding provider is called.
+func (s *AnimeService) Similar(ctx context.Context, id int, opts RecommendOptions) ([]models.ScoredAnime, error) {
+	seed, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.embeddedWithActiveModel(seed) {
+		return nil, ErrSeedNotEmbedded
+	}
+
+	results, err := s.searchVector(ctx, seed.Embedding, opts, map[int]bool{seed.ID: true})
+	if err == nil && opts.Explain {
+		newExplainer([]models.Anime{seed}, "").explain(results)
+	}
+	return results, err
+}
+
+func (s *AnimeService) embeddedWithActiveModel(anime models.Anime) bool {
+	dimensions := s.embedder.Dimensions()
+	if dimensions == 0 {
+		dimensions = len(anime.Embedding)
+	}
+	return repository.EmbeddingCompatible(anime, s.embedder.ModelID(), embeddings.Version(s.embedder), dimensions)
+}
+
+func (s *AnimeService) searchVector(ctx context.Context, vector []float32, opts RecommendOptions, exclude map[int]bool) ([]models.ScoredAnime, error) {
+	opts = s.rankingFor(opts)
+	window := opts.fetchSize(len(exclude))
+	results, err := s.repo.VectorSearch(ctx, repository.VectorQuery{
+		Vector:        vector,
+		Model:         s.embedder.ModelID(),
+		Version:       embeddings.Version(s.embedder),
+		Limit:         window,
+		NumCandidates: min(window*candidatesPerResult, maxNumCandidates),
+		Filter:        opts.Filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vector search error: %w", err)
+	}
+
+	kept := results[:0]
+	for _, result := range results {
+		if !exclude[result.Anime.ID] {
+			kept = append(kept, result)
+		}
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Score > kept[j].Score
+	})
+	return paginate(kept, opts), nil
+}
+
+// paginate drops results below MinScore, blends in the ranking priors,
+// applies diversification when requested and returns the page selected by
+// Offset and Limit. results must be sorted by descending similarity.
+func paginate(results []models.ScoredAnime, opts RecommendOptions) []models.ScoredAnime {
+	kept := make([]models.ScoredAnime, 0, len(results))
+	for _, result := range results {
+		if result.Score < opts.MinScore {
+			break
+		}
+		kept = append(kept, result)
+	}
+
+	blendScores(kept, opts.ranking)
+	if opts.diversified() {
+		kept = diversify(kept, opts.Offset+opts.Limit, opts)
+	}
+
+	if opts.Offset >= len(kept) {
+		return []models.ScoredAnime{}
+	}
+	return kept[opts.Offset:min(len(kept), opts.Offset+opts.Limit)]
+}
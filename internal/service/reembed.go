@@ -0,0 +1,204 @@
+package service
+
+import (
+	"anime/internal/embeddings"
+	"anime/internal/models"
+	"anime/internal/repository"
+	"anime/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const reembedCheckpoint = "reembed"
+
+var ErrReembedRunning = errors.New("re-embed job already running")
+
+type ReembedOptions struct {
+	BatchSize int
+	DryRun    bool
+	// Force re-embeds every document, not only those stamped with another model.
+	Force bool
+	// Restart ignores a saved checkpoint and walks the collection from the start.
+	Restart bool
+}
+
+type reembedCheckpointState struct {
+	Model   string `json:"model" bson:"model"`
+	Version string `json:"version,omitempty" bson:"version,omitempty"`
+	LastID  int    `json:"lastId" bson:"lastId"`
+}
+
+// StartReembed launches a background job that regenerates stored embeddings
+// with the configured embedder. Progress is checkpointed after every batch,
+// so an interrupted job resumes from the last written document.
+func (s *AnimeService) StartReembed(opts ReembedOptions) (models.ReembedStatus, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+
+	s.reembedMu.Lock()
+	defer s.reembedMu.Unlock()
+
+	if s.reembed.Running {
+		return s.reembed, ErrReembedRunning
+	}
+
+	ctx := context.Background()
+	var checkpoint reembedCheckpointState
+	if !opts.DryRun && !opts.Restart {
+		found, err := s.checkpoints.LoadCheckpoint(ctx, reembedCheckpoint, &checkpoint)
+		if err != nil {
+			return s.reembed, fmt.Errorf("load checkpoint error: %w", err)
+		}
+		if found && (checkpoint.Model != s.embedder.ModelID() || checkpoint.Version != embeddings.Version(s.embedder)) {
+			checkpoint = reembedCheckpointState{}
+		}
+	}
+
+	s.reembed = models.ReembedStatus{
+		Running:   true,
+		DryRun:    opts.DryRun,
+		Model:     s.embedder.ModelID(),
+		LastID:    checkpoint.LastID,
+		StartedAt: time.Now().UTC(),
+	}
+
+	go s.runReembed(ctx, opts, checkpoint.LastID)
+
+	return s.reembed, nil
+}
+
+func (s *AnimeService) ReembedStatus() models.ReembedStatus {
+	s.reembedMu.Lock()
+	defer s.reembedMu.Unlock()
+	return s.reembed
+}
+
+func (s *AnimeService) runReembed(ctx context.Context, opts ReembedOptions, lastID int) {
+	err := s.reembedBatches(ctx, opts, lastID)
+
+	s.reembedMu.Lock()
+	s.reembed.Running = false
+	s.reembed.FinishedAt = time.Now().UTC()
+	if err != nil {
+		s.reembed.Error = err.Error()
+	}
+	status := s.reembed
+	s.reembedMu.Unlock()
+
+	if err != nil {
+		log.Printf("Re-embed stopped after %d documents: %v", status.Scanned, err)
+		return
+	}
+
+	if !opts.DryRun {
+		if err := s.checkpoints.DeleteCheckpoint(ctx, reembedCheckpoint); err != nil {
+			log.Println("delete checkpoint error:", err)
+		}
+	}
+	log.Printf("Re-embed finished: scanned %d, stale %d, re-embedded %d, failed %d\n",
+		status.Scanned, status.Stale, status.Reembedded, status.Failed)
+}
+
+func (s *AnimeService) reembedBatches(ctx context.Context, opts ReembedOptions, lastID int) error {
+	model := s.embedder.ModelID()
+
+	for {
+		batch, err := s.repo.Scan(ctx, lastID, opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		var stale []models.Anime
+		for _, anime := range batch {
+			if opts.Force || s.needsReembed(anime) {
+				stale = append(stale, anime)
+			}
+		}
+
+		reembedded, failed := 0, 0
+		if !opts.DryRun && len(stale) > 0 {
+			docs, err := s.embedDocuments(ctx, stale)
+			if err != nil {
+				return err
+			}
+			failed = len(stale) - len(docs)
+
+			if len(docs) > 0 {
+				if _, err := s.repo.Upsert(ctx, docs); err != nil {
+					return fmt.Errorf("upsert error: %w", err)
+				}
+			}
+			reembedded = len(docs)
+		}
+
+		lastID = batch[len(batch)-1].ID
+		if !opts.DryRun {
+			state := reembedCheckpointState{Model: model, Version: embeddings.Version(s.embedder), LastID: lastID}
+			if err := s.checkpoints.SaveCheckpoint(ctx, reembedCheckpoint, state); err != nil {
+				return fmt.Errorf("save checkpoint error: %w", err)
+			}
+		}
+
+		s.reembedMu.Lock()
+		s.reembed.Scanned += len(batch)
+		s.reembed.Stale += len(stale)
+		s.reembed.Reembedded += reembedded
+		s.reembed.Failed += failed
+		s.reembed.LastID = lastID
+		s.reembedMu.Unlock()
+	}
+}
+
+func (s *AnimeService) needsReembed(anime models.Anime) bool {
+	return len(anime.Embedding) == 0 ||
+		!repository.SameEmbeddingModel(anime.EmbeddingInfo, s.embedder.ModelID(), embeddings.Version(s.embedder))
+}
+
+// embedDocuments embeds a batch in one provider call and falls back to one
+// call per document when the batch fails, so one bad document cannot stall
+// the job. Quota errors abort so the job can resume from its checkpoint.
+func (s *AnimeService) embedDocuments(ctx context.Context, animes []models.Anime) ([]models.Anime, error) {
+	texts := make([]string, 0, len(animes))
+	for _, anime := range animes {
+		texts = append(texts, utils.EmbeddingText(anime.Title, anime.Description, anime.Genres))
+	}
+
+	vectors, err := s.embedder.EmbedBatch(ctx, texts)
+	if errors.Is(err, embeddings.ErrQuotaExceeded) {
+		return nil, err
+	}
+	if err != nil {
+		log.Println("batch embedding error, retrying one by one:", err)
+		vectors = make([][]float32, len(texts))
+		for i, text := range texts {
+			vector, err := s.embedder.Embed(ctx, text)
+			if errors.Is(err, embeddings.ErrQuotaExceeded) {
+				return nil, err
+			}
+			if err != nil {
+				log.Printf("embedding error for anime %d: %v", animes[i].ID, err)
+				continue
+			}
+			vectors[i] = vector
+		}
+	}
+
+	docs := make([]models.Anime, 0, len(animes))
+	for i, anime := range animes {
+		if len(vectors[i]) == 0 {
+			continue
+		}
+		anime.Embedding = vectors[i]
+		anime.EmbeddingInfo = embeddings.Info(s.embedder, vectors[i])
+		anime.ContentHash = utils.ContentHash(texts[i])
+		docs = append(docs, anime)
+	}
+	return docs, nil
+}
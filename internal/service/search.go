@@ -0,0 +1,98 @@
+package service
+
+import (
+	"anime/internal/embeddings"
+	"anime/internal/models"
+	"anime/internal/repository"
+	"context"
+	"fmt"
+	"sort"
+)
+
+// rrfK dampens the advantage of top ranks in reciprocal rank fusion; 60 is
+// the value from the original RRF paper.
+const rrfK = 60
+
+// Search runs a keyword ranking and a vector ranking for query and fuses
+// them with reciprocal rank fusion. Each result reports how much its
+// lexical and semantic ranks contributed to the fused score.
+func (s *AnimeService) Search(ctx context.Context, query string, opts RecommendOptions) ([]models.HybridResult, error) {
+	depth := max((opts.Offset+opts.Limit)*3, 50)
+
+	lexical, err := s.repo.TextSearch(ctx, repository.TextQuery{Text: query, Limit: depth, Filter: opts.Filter})
+	if err != nil {
+		return nil, fmt.Errorf("text search error: %w", err)
+	}
+
+	vector, err := s.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	semantic, err := s.repo.VectorSearch(ctx, repository.VectorQuery{
+		Vector:        vector,
+		Model:         s.embedder.ModelID(),
+		Version:       embeddings.Version(s.embedder),
+		Limit:         depth,
+		NumCandidates: min(depth*candidatesPerResult, maxNumCandidates),
+		Filter:        opts.Filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vector search error: %w", err)
+	}
+
+	results := fuseRankings(lexical, semantic)
+
+	page := make([]models.HybridResult, 0, opts.Limit)
+	for i, result := range results {
+		if i < opts.Offset {
+			continue
+		}
+		if len(page) == opts.Limit {
+			break
+		}
+		page = append(page, result)
+	}
+
+	if opts.Explain {
+		e := newExplainer(nil, query)
+		for i := range page {
+			page[i].Explanation = e.explanation(models.ScoredAnime{Anime: page[i].Anime})
+		}
+	}
+	return page, nil
+}
+
+func fuseRankings(lexical, semantic []models.ScoredAnime) []models.HybridResult {
+	fused := make(map[int]*models.HybridResult)
+	get := func(anime models.Anime) *models.HybridResult {
+		result, ok := fused[anime.ID]
+		if !ok {
+			result = &models.HybridResult{Anime: anime}
+			fused[anime.ID] = result
+		}
+		return result
+	}
+
+	for i, hit := range lexical {
+		result := get(hit.Anime)
+		result.Lexical = &models.RankContribution{Rank: i + 1, Score: hit.Score, Contribution: 1.0 / float64(rrfK+i+1)}
+		result.Score += result.Lexical.Contribution
+	}
+	for i, hit := range semantic {
+		result := get(hit.Anime)
+		result.Semantic = &models.RankContribution{Rank: i + 1, Score: hit.Score, Contribution: 1.0 / float64(rrfK+i+1)}
+		result.Score += result.Semantic.Contribution
+	}
+
+	results := make([]models.HybridResult, 0, len(fused))
+	for _, result := range fused {
+		results = append(results, *result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Anime.ID < results[j].Anime.ID
+	})
+	return results
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"anime/internal/embeddings"
+	"anime/internal/models"
+	"anime/internal/repository"
+	"anime/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+const (
+	StrategyCentroid = "centroid"
+	StrategyFusion   = "fusion"
+
+	MaxSeeds = 20
+
+	defaultLikeWeight    = 1.0
+	defaultDislikeWeight = 0.5
+
+	// minCentroidShare is how short the combined centroid may get relative
+	// to the likes alone before it is considered cancelled out.
+	minCentroidShare = 0.01
+)
+
+var ErrInvalidSeeds = errors.New("invalid seeds")
+
+type weightedVector struct {
+	vector []float32
+	weight float64
+	// seed is the anime the vector belongs to; zero for the query.
+	seed models.Anime
+}
+
+// RecommendFromSeeds ranks animes against several liked and disliked seeds
+// and an optional text query. The centroid strategy searches with the
+// weighted mean of liked vectors minus the weighted disliked vectors; the
+// fusion strategy searches around each positive seed and scores every
+// candidate by its weighted similarity to all seeds. Seeds are never
+// returned.
+func (s *AnimeService) RecommendFromSeeds(ctx context.Context, req models.RecommendRequest, opts RecommendOptions) ([]models.ScoredAnime, error) {
+	if len(req.Likes) == 0 && req.Query == "" {
+		return nil, fmt.Errorf("%w: at least one liked anime or a query is required", ErrInvalidSeeds)
+	}
+	if len(req.Likes)+len(req.Dislikes) > MaxSeeds {
+		return nil, fmt.Errorf("%w: at most %d seeds are allowed", ErrInvalidSeeds, MaxSeeds)
+	}
+	liked := make(map[int]bool, len(req.Likes))
+	for _, ref := range req.Likes {
+		liked[ref.ID] = true
+	}
+	for _, ref := range req.Dislikes {
+		if liked[ref.ID] {
+			return nil, fmt.Errorf("%w: anime %d is both liked and disliked", ErrInvalidSeeds, ref.ID)
+		}
+	}
+
+	exclude := make(map[int]bool)
+	likes, err := s.loadSeeds(ctx, req.Likes, defaultLikeWeight, exclude)
+	if err != nil {
+		return nil, err
+	}
+	dislikes, err := s.loadSeeds(ctx, req.Dislikes, defaultDislikeWeight, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Query != "" {
+		vector, err := s.EmbedQuery(ctx, req.Query)
+		if err != nil {
+			return nil, err
+		}
+		likes = append(likes, weightedVector{vector: vector, weight: weightOr(req.QueryWeight, defaultLikeWeight)})
+	}
+
+	var results []models.ScoredAnime
+	switch req.Strategy {
+	case "", StrategyCentroid:
+		results, err = s.searchVector(ctx, centroid(likes, dislikes), opts, exclude)
+	case StrategyFusion:
+		results, err = s.fuseSeeds(ctx, likes, dislikes, opts, exclude)
+	default:
+		return nil, fmt.Errorf("%w: unknown strategy %q", ErrInvalidSeeds, req.Strategy)
+	}
+
+	if err == nil && opts.Explain {
+		seeds := make([]models.Anime, 0, len(likes))
+		for _, like := range likes {
+			if like.seed.ID != 0 {
+				seeds = append(seeds, like.seed)
+			}
+		}
+		newExplainer(seeds, req.Query).explain(results)
+	}
+	return results, err
+}
+
+func (s *AnimeService) loadSeeds(ctx context.Context, refs []models.SeedRef, defaultWeight float64, exclude map[int]bool) ([]weightedVector, error) {
+	seeds := make([]weightedVector, 0, len(refs))
+	for _, ref := range refs {
+		weight := weightOr(ref.Weight, defaultWeight)
+		if weight < 0 {
+			return nil, fmt.Errorf("%w: weight for anime %d must not be negative", ErrInvalidSeeds, ref.ID)
+		}
+
+		anime, err := s.repo.GetByID(ctx, ref.ID)
+		if err != nil {
+			return nil, fmt.Errorf("seed %d: %w", ref.ID, err)
+		}
+		if !s.embeddedWithActiveModel(anime) {
+			return nil, fmt.Errorf("seed %d: %w", ref.ID, ErrSeedNotEmbedded)
+		}
+
+		exclude[anime.ID] = true
+		seeds = append(seeds, weightedVector{vector: anime.Embedding, weight: weight, seed: anime})
+	}
+	return seeds, nil
+}
+
+func weightOr(weight *float64, fallback float64) float64 {
+	if weight == nil {
+		return fallback
+	}
+	return *weight
+}
+
+// centroid combines unit-length seed vectors into one query vector. Weights
+// are relative to the total liked weight, so a dislike weighted 0.5 pulls
+// away half as strongly as all likes together pull towards. When dislikes
+// all but cancel the likes, the result has no meaningful direction and the
+// likes alone are used.
+func centroid(likes, dislikes []weightedVector) []float32 {
+	var total float64
+	for _, like := range likes {
+		total += like.weight
+	}
+	if total == 0 {
+		total = 1
+	}
+
+	liked := make([]float64, len(likes[0].vector))
+	for _, like := range likes {
+		addScaled(liked, like.vector, like.weight/total)
+	}
+	sum := slices.Clone(liked)
+	for _, dislike := range dislikes {
+		addScaled(sum, dislike.vector, -dislike.weight/total)
+	}
+	if vectorNorm(sum) < minCentroidShare*vectorNorm(liked) {
+		sum = liked
+	}
+
+	out := make([]float32, len(sum))
+	for i, v := range sum {
+		out[i] = float32(v)
+	}
+	return out
+}
+
+func vectorNorm(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
+
+func addScaled(dst []float64, v []float32, scale float64) {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 || len(v) != len(dst) {
+		return
+	}
+	scale /= math.Sqrt(norm)
+	for i, x := range v {
+		dst[i] += float64(x) * scale
+	}
+}
+
+func (s *AnimeService) fuseSeeds(ctx context.Context, likes, dislikes []weightedVector, opts RecommendOptions, exclude map[int]bool) ([]models.ScoredAnime, error) {
+	opts = s.rankingFor(opts)
+	window := opts.fetchSize(len(exclude))
+	candidates := make(map[int]models.Anime)
+	for _, like := range likes {
+		results, err := s.repo.VectorSearch(ctx, repository.VectorQuery{
+			Vector:        like.vector,
+			Model:         s.embedder.ModelID(),
+			Version:       embeddings.Version(s.embedder),
+			Limit:         window,
+			NumCandidates: min(window*candidatesPerResult, maxNumCandidates),
+			Filter:        opts.Filter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("vector search error: %w", err)
+		}
+		for _, result := range results {
+			if !exclude[result.Anime.ID] {
+				candidates[result.Anime.ID] = result.Anime
+			}
+		}
+	}
+
+	var total float64
+	for _, like := range likes {
+		total += like.weight
+	}
+	if total == 0 {
+		total = 1
+	}
+
+	scored := make([]models.ScoredAnime, 0, len(candidates))
+	for _, anime := range candidates {
+		var score float64
+		for _, like := range likes {
+			score += like.weight / total * similarity(anime.Embedding, like.vector)
+		}
+		for _, dislike := range dislikes {
+			score -= dislike.weight / total * similarity(anime.Embedding, dislike.vector)
+		}
+		scored = append(scored, models.ScoredAnime{Anime: anime, Score: score})
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Anime.ID < scored[j].Anime.ID
+	})
+	return paginate(scored, opts), nil
+}
+
+func similarity(a, b []float32) float64 {
+	score, err := utils.CosineSimilarity(a, b)
+	if err != nil {
+		return 0
+	}
+	return score
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"anime/internal/embeddings"
+	"anime/internal/models"
+	"anime/internal/repository"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCentroid(t *testing.T) {
+	x := []float32{1, 0, 0}
+	y := []float32{0, 1, 0}
+	nearX := []float32{1, 0.001, 0}
+
+	tests := []struct {
+		name     string
+		likes    []weightedVector
+		dislikes []weightedVector
+		want     []float32
+	}{
+		{"one like", []weightedVector{{vector: x, weight: 1}}, nil, x},
+		{"two likes", []weightedVector{{vector: x, weight: 1}, {vector: y, weight: 1}}, nil, []float32{1, 1, 0}},
+		{"dislike pulls away", []weightedVector{{vector: x, weight: 1}}, []weightedVector{{vector: y, weight: 0.5}}, []float32{1, -0.5, 0}},
+		{"cancelled dislike falls back to likes", []weightedVector{{vector: x, weight: 1}, {vector: y, weight: 1}}, []weightedVector{{vector: x, weight: 1}, {vector: y, weight: 1}}, []float32{1, 1, 0}},
+		{"near cancellation falls back to likes", []weightedVector{{vector: x, weight: 1}}, []weightedVector{{vector: nearX, weight: 1}}, x},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := centroid(tt.likes, tt.dislikes)
+			if sim := similarity(got, tt.want); sim < 0.999 {
+				t.Errorf("centroid = %v, want direction %v (cosine %.4f)", got, tt.want, sim)
+			}
+		})
+	}
+}
+
+func TestRecommendFromSeedsValidation(t *testing.T) {
+	s := NewAnimeService(repository.NewMemoryAnimeRepository(), embeddings.NewHashEmbedder(64), repository.NewMemoryCheckpointStore(), nil)
+
+	tests := []struct {
+		name    string
+		req     models.RecommendRequest
+		wantErr error
+	}{
+		{"nothing liked", models.RecommendRequest{Dislikes: []models.SeedRef{{ID: 1}}}, ErrInvalidSeeds},
+		{"liked and disliked", models.RecommendRequest{Likes: []models.SeedRef{{ID: 1}, {ID: 2}}, Dislikes: []models.SeedRef{{ID: 2}}}, ErrInvalidSeeds},
+		{"unknown seed", models.RecommendRequest{Likes: []models.SeedRef{{ID: 1}}}, repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.RecommendFromSeeds(context.Background(), tt.req, RecommendOptions{Limit: 10}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"anime/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+const (
+	syncCheckpoint = "sync"
+	// syncWatermark holds the AniList updatedAt up to which the local
+	// catalog is known to be current.
+	syncWatermark = "sync-watermark"
+)
+
+var (
+	ErrSyncRunning = errors.New("sync job already running")
+	ErrNoWatermark = errors.New("no sync high-water mark recorded, run a full unfiltered sync first")
+	ErrSyncFilters = errors.New("incremental sync does not support filters")
+)
+
+type SyncOptions struct {
+	PerPage       int64
+	Formats       []string
+	MinYear       int
+	MaxYear       int
+	MinPopularity int
+	// Restart ignores a saved cursor and starts again from the first page.
+	Restart bool
+	// Incremental walks AniList by most recently updated and stops at the
+	// high-water mark of the previous sync. It cannot be combined with
+	// filters.
+	Incremental bool
+}
+
+func (o SyncOptions) filtered() bool {
+	return len(o.Formats) > 0 || o.MinYear > 0 || o.MaxYear > 0 || o.MinPopularity > 0
+}
+
+type syncCheckpointState struct {
+	Query    models.AniListQuery `json:"query" bson:"query"`
+	NextPage int64               `json:"nextPage" bson:"nextPage"`
+	// StartedAt is when the sync first started, kept across resumes so the
+	// high-water mark covers pages ingested before an interruption.
+	StartedAt time.Time `json:"startedAt" bson:"startedAt"`
+}
+
+type syncWatermarkState struct {
+	UpdatedAt int64 `json:"updatedAt" bson:"updatedAt"`
+}
+
+// StartSync launches a background job that ingests the AniList catalog
+// matching opts, following pageInfo until there is no next page. A full
+// sync checkpoints the next page after every page, so an interrupted sync
+// with the same filters resumes where it stopped. An incremental sync only
+// fetches animes updated since the last successful sync.
+func (s *AnimeService) StartSync(opts SyncOptions) (models.SyncStatus, error) {
+	if opts.PerPage <= 0 || opts.PerPage > 50 {
+		opts.PerPage = 50
+	}
+	if opts.Incremental && opts.filtered() {
+		return models.SyncStatus{}, ErrSyncFilters
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if s.sync.Running {
+		return s.sync, ErrSyncRunning
+	}
+
+	// Sorting by ID keeps page boundaries stable while a full sync runs;
+	// popularity order shifts between requests and would skip animes.
+	query := models.AniListQuery{
+		Page:          1,
+		PerPage:       opts.PerPage,
+		Sort:          "ID",
+		Formats:       opts.Formats,
+		MinYear:       opts.MinYear,
+		MaxYear:       opts.MaxYear,
+		MinPopularity: opts.MinPopularity,
+	}
+
+	ctx := context.Background()
+	started := time.Now().UTC()
+	var since int64
+	if opts.Incremental {
+		var watermark syncWatermarkState
+		found, err := s.checkpoints.LoadCheckpoint(ctx, syncWatermark, &watermark)
+		if err != nil {
+			return s.sync, fmt.Errorf("load checkpoint error: %w", err)
+		}
+		if !found {
+			return s.sync, ErrNoWatermark
+		}
+		query.Sort = "UPDATED_AT_DESC"
+		since = watermark.UpdatedAt
+	} else if !opts.Restart {
+		var checkpoint syncCheckpointState
+		found, err := s.checkpoints.LoadCheckpoint(ctx, syncCheckpoint, &checkpoint)
+		if err != nil {
+			return s.sync, fmt.Errorf("load checkpoint error: %w", err)
+		}
+		if found && sameSyncQuery(checkpoint.Query, query) {
+			query.Page = checkpoint.NextPage
+			if !checkpoint.StartedAt.IsZero() {
+				started = checkpoint.StartedAt
+			}
+		}
+	}
+
+	s.sync = models.SyncStatus{
+		Running:     true,
+		Incremental: opts.Incremental,
+		Query:       query,
+		Since:       since,
+		Page:        query.Page,
+		StartedAt:   time.Now().UTC(),
+	}
+
+	go s.runSync(ctx, opts, query, since, started)
+
+	return s.sync, nil
+}
+
+func (s *AnimeService) SyncStatus() models.SyncStatus {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	return s.sync
+}
+
+func sameSyncQuery(a, b models.AniListQuery) bool {
+	return a.PerPage == b.PerPage && a.Sort == b.Sort && slices.Equal(a.Formats, b.Formats) &&
+		a.MinYear == b.MinYear && a.MaxYear == b.MaxYear && a.MinPopularity == b.MinPopularity
+}
+
+// runSync runs the sync job. started is when the sync first started, which
+// is earlier than now when it resumed from a checkpoint.
+func (s *AnimeService) runSync(ctx context.Context, opts SyncOptions, query models.AniListQuery, since int64, started time.Time) {
+	var (
+		watermark int64
+		err       error
+	)
+	if opts.Incremental {
+		watermark, err = s.syncUpdated(ctx, query, since)
+	} else {
+		err = s.syncPages(ctx, query, started)
+		// Anything AniList changes after this point is picked up by the
+		// next incremental sync. A filtered sync does not cover the whole
+		// catalog, so it cannot move the mark.
+		if !opts.filtered() {
+			watermark = started.Unix()
+		}
+	}
+	s.rebuildSuggestions()
+
+	s.syncMu.Lock()
+	s.sync.Running = false
+	s.sync.FinishedAt = time.Now().UTC()
+	if err != nil {
+		s.sync.Error = err.Error()
+	}
+	status := s.sync
+	s.syncMu.Unlock()
+
+	if err != nil {
+		log.Printf("Sync stopped at page %d: %v", status.Page, err)
+		return
+	}
+
+	if !opts.Incremental {
+		if err := s.checkpoints.DeleteCheckpoint(ctx, syncCheckpoint); err != nil {
+			log.Println("delete checkpoint error:", err)
+		}
+	}
+	if watermark > 0 {
+		if err := s.checkpoints.SaveCheckpoint(ctx, syncWatermark, syncWatermarkState{UpdatedAt: watermark}); err != nil {
+			log.Println("save checkpoint error:", err)
+		}
+	}
+
+	r := status.Report
+	log.Printf("Sync finished after %d pages: %d inserted, %d updated, %d unchanged, %d failed\n",
+		status.Page, r.Inserted, r.Updated, r.Unchanged, r.Failed)
+}
+
+func (s *AnimeService) syncPages(ctx context.Context, query models.AniListQuery, started time.Time) error {
+	for {
+		animes, pageInfo, err := s.syncPage(ctx, query)
+		if err != nil {
+			return err
+		}
+		if err := s.ingestSyncPage(ctx, query, animes); err != nil {
+			return err
+		}
+		if !pageInfo.HasNextPage {
+			return nil
+		}
+
+		query.Page++
+		state := syncCheckpointState{Query: query, NextPage: query.Page, StartedAt: started}
+		if err := s.checkpoints.SaveCheckpoint(ctx, syncCheckpoint, state); err != nil {
+			return fmt.Errorf("save checkpoint error: %w", err)
+		}
+	}
+}
+
+// syncUpdated walks animes from most to least recently updated and stops at
+// the first one not updated after since. It returns the new high-water mark.
+func (s *AnimeService) syncUpdated(ctx context.Context, query models.AniListQuery, since int64) (int64, error) {
+	watermark := since
+	for {
+		animes, pageInfo, err := s.syncPage(ctx, query)
+		if err != nil {
+			return 0, err
+		}
+
+		done := !pageInfo.HasNextPage
+		updated := animes[:0]
+		for _, anime := range animes {
+			if anime.UpdatedAt <= since {
+				done = true
+				continue
+			}
+			watermark = max(watermark, anime.UpdatedAt)
+			updated = append(updated, anime)
+		}
+
+		if err := s.ingestSyncPage(ctx, query, updated); err != nil {
+			return 0, err
+		}
+		if done {
+			return watermark, nil
+		}
+
+		query.Page++
+	}
+}
+
+func (s *AnimeService) syncPage(ctx context.Context, query models.AniListQuery) ([]models.AnimeResponse, models.PageInfo, error) {
+	animes, pageInfo, err := s.anilist.FetchAnimePage(ctx, query)
+	if err != nil {
+		return nil, pageInfo, fmt.Errorf("fetch page %d error: %w", query.Page, err)
+	}
+
+	s.syncMu.Lock()
+	s.sync.Page = query.Page
+	s.sync.LastPage = pageInfo.LastPage
+	s.sync.Total = pageInfo.Total
+	s.syncMu.Unlock()
+	return animes, pageInfo, nil
+}
+
+func (s *AnimeService) ingestSyncPage(ctx context.Context, query models.AniListQuery, animes []models.AnimeResponse) error {
+	report, err := s.ingest(ctx, animes)
+
+	s.syncMu.Lock()
+	s.sync.Report.Add(report)
+	s.syncMu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("ingest page %d error: %w", query.Page, err)
+	}
+	return nil
+}
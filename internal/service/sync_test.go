@@ -0,0 +1,295 @@
+package service
+
+import (
+	"anime/internal/embeddings"
+	"anime/internal/models"
+	"anime/internal/repository"
+	"anime/internal/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	fakePerPage  = 2
+	fakeLastPage = 4
+)
+
+// fakeAniList serves fakeLastPage pages of fakePerPage animes sorted by ID
+// and records which pages were requested. failPage, when set, answers that
+// page with a non-retryable error.
+type fakeAniList struct {
+	mu       sync.Mutex
+	pages    []int64
+	failPage int64
+}
+
+func (f *fakeAniList) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Variables struct {
+			Page    int64 `json:"page"`
+			PerPage int64 `json:"perPage"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page := req.Variables.Page
+
+	f.mu.Lock()
+	f.pages = append(f.pages, page)
+	failing := page == f.failPage
+	f.mu.Unlock()
+
+	if failing {
+		http.Error(w, "bad page", http.StatusBadRequest)
+		return
+	}
+
+	var media []map[string]any
+	for i := range fakePerPage {
+		id := int(page)*10 + i
+		media = append(media, map[string]any{
+			"id":          id,
+			"title":       map[string]any{"romaji": fmt.Sprintf("Anime %d", id)},
+			"description": fmt.Sprintf("Description of anime %d.", id),
+			"genres":      []string{"Action"},
+			"updatedAt":   fakeUpdatedAt(id),
+			"studios":     map[string]any{"nodes": []map[string]any{{"name": "Studio"}}},
+		})
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": map[string]any{
+			"Page": map[string]any{
+				"pageInfo": map[string]any{
+					"total":       fakeLastPage * fakePerPage,
+					"currentPage": page,
+					"lastPage":    fakeLastPage,
+					"hasNextPage": page < fakeLastPage,
+					"perPage":     fakePerPage,
+				},
+				"media": media,
+			},
+		},
+	})
+}
+
+// fakeUpdatedAt makes later IDs older, so pages in ID order are also in
+// UPDATED_AT_DESC order.
+func fakeUpdatedAt(id int) int64 {
+	return int64(1000 - id)
+}
+
+func (f *fakeAniList) requested() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.pages)
+}
+
+func newSyncService(t *testing.T, fake *fakeAniList) (*AnimeService, *repository.MemoryAnimeRepository, *repository.MemoryCheckpointStore) {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	anilist := utils.NewAniListClient(6000)
+	anilist.Endpoint = server.URL
+
+	repo := repository.NewMemoryAnimeRepository()
+	checkpoints := repository.NewMemoryCheckpointStore()
+	return NewAnimeService(repo, embeddings.NewHashEmbedder(64), checkpoints, anilist), repo, checkpoints
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func loadCheckpoint(t *testing.T, checkpoints repository.CheckpointStore, name string, value any) bool {
+	t.Helper()
+	found, err := checkpoints.LoadCheckpoint(context.Background(), name, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+func TestSyncCheckpointResume(t *testing.T) {
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fullQuery := models.AniListQuery{Page: 3, PerPage: fakePerPage, Sort: "ID"}
+	otherQuery := models.AniListQuery{Page: 3, PerPage: fakePerPage, Sort: "ID", MinYear: 2000}
+
+	tests := []struct {
+		name       string
+		checkpoint *syncCheckpointState
+		opts       SyncOptions
+		wantPages  []int64
+		// wantResumed means the watermark comes from the checkpoint's
+		// StartedAt rather than the time of this run.
+		wantResumed bool
+	}{
+		{"no checkpoint", nil, SyncOptions{}, []int64{1, 2, 3, 4}, false},
+		{"resume", &syncCheckpointState{Query: fullQuery, NextPage: 3, StartedAt: started}, SyncOptions{}, []int64{3, 4}, true},
+		{"restart", &syncCheckpointState{Query: fullQuery, NextPage: 3, StartedAt: started}, SyncOptions{Restart: true}, []int64{1, 2, 3, 4}, false},
+		{"different filters", &syncCheckpointState{Query: otherQuery, NextPage: 3, StartedAt: started}, SyncOptions{}, []int64{1, 2, 3, 4}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeAniList{}
+			s, repo, checkpoints := newSyncService(t, fake)
+			if tt.checkpoint != nil {
+				if err := checkpoints.SaveCheckpoint(context.Background(), syncCheckpoint, tt.checkpoint); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			before := time.Now()
+			tt.opts.PerPage = fakePerPage
+			if _, err := s.StartSync(tt.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			var watermark syncWatermarkState
+			waitFor(t, "the sync watermark", func() bool {
+				return loadCheckpoint(t, checkpoints, syncWatermark, &watermark)
+			})
+
+			status := s.SyncStatus()
+			if status.Running || status.Error != "" {
+				t.Fatalf("status = %+v, want finished without error", status)
+			}
+			if got := fake.requested(); !slices.Equal(got, tt.wantPages) {
+				t.Errorf("requested pages %v, want %v", got, tt.wantPages)
+			}
+			want := len(tt.wantPages) * fakePerPage
+			if status.Report.Inserted != want {
+				t.Errorf("inserted %d, want %d", status.Report.Inserted, want)
+			}
+			if animes, _ := repo.List(context.Background()); len(animes) != want {
+				t.Errorf("stored %d animes, want %d", len(animes), want)
+			}
+
+			switch {
+			case tt.wantResumed && watermark.UpdatedAt != started.Unix():
+				t.Errorf("watermark = %d, want the checkpoint's start %d", watermark.UpdatedAt, started.Unix())
+			case !tt.wantResumed && watermark.UpdatedAt < before.Unix():
+				t.Errorf("watermark = %d, want at least this run's start %d", watermark.UpdatedAt, before.Unix())
+			}
+
+			var left syncCheckpointState
+			if loadCheckpoint(t, checkpoints, syncCheckpoint, &left) {
+				t.Errorf("checkpoint %+v left after a finished sync", left)
+			}
+		})
+	}
+}
+
+func TestSyncInterruptedThenResumed(t *testing.T) {
+	fake := &fakeAniList{failPage: 3}
+	s, _, checkpoints := newSyncService(t, fake)
+
+	if _, err := s.StartSync(SyncOptions{PerPage: fakePerPage}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the first sync to stop", func() bool { return !s.SyncStatus().Running })
+	if s.SyncStatus().Error == "" {
+		t.Fatal("sync reported no error for the failing page")
+	}
+
+	var checkpoint syncCheckpointState
+	if !loadCheckpoint(t, checkpoints, syncCheckpoint, &checkpoint) {
+		t.Fatal("no checkpoint saved after the failure")
+	}
+	if checkpoint.NextPage != 3 {
+		t.Errorf("checkpoint.NextPage = %d, want 3", checkpoint.NextPage)
+	}
+	var watermark syncWatermarkState
+	if loadCheckpoint(t, checkpoints, syncWatermark, &watermark) {
+		t.Errorf("watermark %d saved by a failed sync", watermark.UpdatedAt)
+	}
+
+	fake.mu.Lock()
+	fake.failPage = 0
+	fake.mu.Unlock()
+
+	if _, err := s.StartSync(SyncOptions{PerPage: fakePerPage}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the sync watermark", func() bool {
+		return loadCheckpoint(t, checkpoints, syncWatermark, &watermark)
+	})
+
+	if got, want := fake.requested(), []int64{1, 2, 3, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("requested pages %v, want %v", got, want)
+	}
+	if watermark.UpdatedAt != checkpoint.StartedAt.Unix() {
+		t.Errorf("watermark = %d, want the first run's start %d", watermark.UpdatedAt, checkpoint.StartedAt.Unix())
+	}
+}
+
+func TestIncrementalSync(t *testing.T) {
+	tests := []struct {
+		name          string
+		watermark     int64
+		opts          SyncOptions
+		wantErr       error
+		wantPages     []int64
+		wantInserted  int
+		wantWatermark int64
+	}{
+		{"stops at the watermark", fakeUpdatedAt(30), SyncOptions{}, nil, []int64{1, 2, 3}, 4, fakeUpdatedAt(10)},
+		{"stops on the first page", fakeUpdatedAt(20), SyncOptions{}, nil, []int64{1, 2}, 2, fakeUpdatedAt(10)},
+		{"nothing changed", fakeUpdatedAt(10), SyncOptions{}, nil, []int64{1}, 0, fakeUpdatedAt(10)},
+		{"no watermark", 0, SyncOptions{}, ErrNoWatermark, nil, 0, 0},
+		{"filters", fakeUpdatedAt(30), SyncOptions{MinYear: 2000}, ErrSyncFilters, nil, 0, fakeUpdatedAt(30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeAniList{}
+			s, _, checkpoints := newSyncService(t, fake)
+			if tt.watermark > 0 {
+				if err := checkpoints.SaveCheckpoint(context.Background(), syncWatermark, syncWatermarkState{UpdatedAt: tt.watermark}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tt.opts.PerPage = fakePerPage
+			tt.opts.Incremental = true
+			if _, err := s.StartSync(tt.opts); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("StartSync error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var watermark syncWatermarkState
+			waitFor(t, "the new watermark", func() bool {
+				loadCheckpoint(t, checkpoints, syncWatermark, &watermark)
+				return !s.SyncStatus().Running && watermark.UpdatedAt == tt.wantWatermark
+			})
+
+			if got := fake.requested(); !slices.Equal(got, tt.wantPages) {
+				t.Errorf("requested pages %v, want %v", got, tt.wantPages)
+			}
+			if status := s.SyncStatus(); status.Report.Inserted != tt.wantInserted || status.Error != "" {
+				t.Errorf("inserted %d (error %q), want %d", status.Report.Inserted, status.Error, tt.wantInserted)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package textindex
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Field is one weighted piece of text belonging to a document. Terms in a
+// field with weight 3 count as if they appeared three times.
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+type Result struct {
+	ID    int
+	Score float64
+	// Terms lists the query terms that matched the document.
+	Terms []string
+}
+
+// BM25 is an in-memory inverted index ranking documents with Okapi BM25
+// over weighted fields.
+type BM25 struct {
+	mu       sync.RWMutex
+	postings map[string]map[int]float64
+	docTerms map[int][]string
+	docLen   map[int]float64
+	totalLen float64
+}
+
+func New() *BM25 {
+	return &BM25{
+		postings: make(map[string]map[int]float64),
+		docTerms: make(map[int][]string),
+		docLen:   make(map[int]float64),
+	}
+}
+
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Add indexes or re-indexes the document with the given ID.
+func (x *BM25) Add(id int, fields ...Field) {
+	tf := make(map[string]float64)
+	var length float64
+	for _, field := range fields {
+		for _, term := range Tokenize(field.Text) {
+			tf[term] += field.Weight
+			length += field.Weight
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+	terms := make([]string, 0, len(tf))
+	for term, freq := range tf {
+		postings, ok := x.postings[term]
+		if !ok {
+			postings = make(map[int]float64)
+			x.postings[term] = postings
+		}
+		postings[id] = freq
+		terms = append(terms, term)
+	}
+	x.docTerms[id] = terms
+	x.docLen[id] = length
+	x.totalLen += length
+}
+
+func (x *BM25) Remove(id int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+func (x *BM25) remove(id int) {
+	for _, term := range x.docTerms[id] {
+		delete(x.postings[term], id)
+		if len(x.postings[term]) == 0 {
+			delete(x.postings, term)
+		}
+	}
+	x.totalLen -= x.docLen[id]
+	delete(x.docTerms, id)
+	delete(x.docLen, id)
+}
+
+// Search returns up to limit documents ranked by BM25 score for query.
+// accept, when non-nil, drops documents before ranking.
+func (x *BM25) Search(query string, limit int, accept func(id int) bool) []Result {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	n := float64(len(x.docLen))
+	if n == 0 {
+		return nil
+	}
+	avgLen := x.totalLen / n
+
+	seen := make(map[string]bool)
+	scores := make(map[int]*Result)
+	for _, term := range Tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := x.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id, tf := range postings {
+			if accept != nil && !accept(id) {
+				continue
+			}
+			norm := tf * (k1 + 1) / (tf + k1*(1-b+b*x.docLen[id]/avgLen))
+			result, ok := scores[id]
+			if !ok {
+				result = &Result{ID: id}
+				scores[id] = result
+			}
+			result.Score += idf * norm
+			result.Terms = append(result.Terms, term)
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for _, result := range scores {
+		results = append(results, *result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,90 @@
+package textindex
+
+import (
+	"slices"
+	"testing"
+)
+
+func newFixtureIndex() *BM25 {
+	x := New()
+	docs := []struct {
+		id          int
+		title       string
+		description string
+	}{
+		{1, "Attack on Titan", "Humanity fights for survival behind walls."},
+		{2, "Cowboy Bebop", "Bounty hunters travel through space. A titan of the genre."},
+		{3, "Space Dandy", "A dandy alien hunter travels space looking for rare aliens."},
+		{4, "Mushishi", "A quiet wanderer studies strange lifeforms."},
+	}
+	for _, doc := range docs {
+		x.Add(doc.id, Field{Text: doc.title, Weight: 3}, Field{Text: doc.description, Weight: 1})
+	}
+	return x
+}
+
+func resultIDs(results []Result) []int {
+	ids := []int{}
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestBM25Ranking(t *testing.T) {
+	x := newFixtureIndex()
+
+	tests := []struct {
+		name   string
+		query  string
+		limit  int
+		accept func(int) bool
+		want   []int
+	}{
+		{"title outweighs description", "titan", 0, nil, []int{1, 2}},
+		{"rare term outweighs common term", "space hunter", 0, nil, []int{3, 2}},
+		{"case and punctuation ignored", "COWBOY-bebop!", 0, nil, []int{2}},
+		{"more matched terms rank higher", "dandy alien", 0, nil, []int{3}},
+		{"limit", "space travel titan", 1, nil, []int{2}},
+		{"accept drops documents", "titan", 0, func(id int) bool { return id != 1 }, []int{2}},
+		{"no match", "mecha", 0, nil, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resultIDs(x.Search(tt.query, tt.limit, tt.accept)); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBM25MatchedTerms(t *testing.T) {
+	x := newFixtureIndex()
+
+	results := x.Search("space titan space", 0, nil)
+	if len(results) == 0 || results[0].ID != 2 {
+		t.Fatalf("Search = %v, want Cowboy Bebop first", results)
+	}
+	terms := slices.Sorted(slices.Values(results[0].Terms))
+	if want := []string{"space", "titan"}; !slices.Equal(terms, want) {
+		t.Errorf("Terms = %v, want %v", terms, want)
+	}
+}
+
+func TestBM25Reindex(t *testing.T) {
+	x := newFixtureIndex()
+
+	x.Remove(1)
+	if got := resultIDs(x.Search("titan", 0, nil)); !slices.Equal(got, []int{2}) {
+		t.Errorf("after Remove: %v, want [2]", got)
+	}
+
+	x.Add(4, Field{Text: "Titan Mushishi", Weight: 3})
+	if got := resultIDs(x.Search("titan", 0, nil)); !slices.Equal(got, []int{4, 2}) {
+		t.Errorf("after re-Add: %v, want [4 2]", got)
+	}
+	if got := resultIDs(x.Search("wanderer", 0, nil)); len(got) != 0 {
+		t.Errorf("re-Add kept old terms: %v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package titleindex
+
+import (
+	"sort"
+	"strings"
+)
+
+// Doc is one document offered as a suggestion.
+type Doc struct {
+	ID         int
+	Titles     []string
+	Popularity int
+}
+
+type Suggestion struct {
+	ID         int
+	Title      string
+	Popularity int
+}
+
+type prefixKey struct {
+	key   string
+	doc   int32
+	title int32
+	// start is true when key is the whole normalized title rather than
+	// the tail beginning at a later word.
+	start bool
+}
+
+// Suggester answers prefix queries over titles. It is immutable; build a
+// new one with NewSuggester when the titles change.
+type Suggester struct {
+	docs []Doc
+	keys []prefixKey
+}
+
+// NewSuggester indexes every word-start suffix of every title, so "hero"
+// suggests "My Hero Academia".
+func NewSuggester(docs []Doc) *Suggester {
+	s := &Suggester{docs: docs}
+	for d, doc := range docs {
+		for t, title := range doc.Titles {
+			key := Normalize(title)
+			for i := 0; i < len(key); i++ {
+				if i == 0 || key[i-1] == ' ' {
+					s.keys = append(s.keys, prefixKey{key: key[i:], doc: int32(d), title: int32(t), start: i == 0})
+				}
+			}
+		}
+	}
+	sort.Slice(s.keys, func(i, j int) bool { return s.keys[i].key < s.keys[j].key })
+	return s
+}
+
+func (s *Suggester) Len() int {
+	return len(s.docs)
+}
+
+// Suggest returns up to limit titles starting with prefix, or containing a
+// word that does. Whole-title matches rank first, then titles starting with
+// prefix, then word matches; ties go to the more popular document.
+func (s *Suggester) Suggest(prefix string, limit int) []Suggestion {
+	p := Normalize(prefix)
+	if p == "" {
+		return nil
+	}
+
+	type hit struct {
+		quality int
+		doc     int32
+		title   int32
+	}
+	best := make(map[int32]hit)
+	for i := sort.Search(len(s.keys), func(i int) bool { return s.keys[i].key >= p }); i < len(s.keys); i++ {
+		k := s.keys[i]
+		if !strings.HasPrefix(k.key, p) {
+			break
+		}
+
+		quality := 0
+		if k.start {
+			quality = 1
+			if k.key == p {
+				quality = 2
+			}
+		}
+		if current, ok := best[k.doc]; !ok || quality > current.quality {
+			best[k.doc] = hit{quality: quality, doc: k.doc, title: k.title}
+		}
+	}
+
+	hits := make([]hit, 0, len(best))
+	for _, h := range best {
+		hits = append(hits, h)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.quality != b.quality {
+			return a.quality > b.quality
+		}
+		if pa, pb := s.docs[a.doc].Popularity, s.docs[b.doc].Popularity; pa != pb {
+			return pa > pb
+		}
+		return s.docs[a.doc].ID < s.docs[b.doc].ID
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	suggestions := make([]Suggestion, 0, len(hits))
+	for _, h := range hits {
+		doc := s.docs[h.doc]
+		suggestions = append(suggestions, Suggestion{ID: doc.ID, Title: doc.Titles[h.title], Popularity: doc.Popularity})
+	}
+	return suggestions
+}
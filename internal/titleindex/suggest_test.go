@@ -0,0 +1,43 @@
+package titleindex
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	s := NewSuggester([]Doc{
+		{ID: 1, Titles: []string{"Boku no Hero Academia", "My Hero Academia"}, Popularity: 900},
+		{ID: 2, Titles: []string{"Hero"}, Popularity: 10},
+		{ID: 3, Titles: []string{"Heroic Age"}, Popularity: 50},
+		{ID: 4, Titles: []string{"Shingeki no Kyojin", "Attack on Titan"}, Popularity: 1000},
+		{ID: 5, Titles: []string{"Shinsekai Yori"}, Popularity: 300},
+	})
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []int
+	}{
+		{"whole title first, then title starts, then words", "hero", 0, []int{2, 3, 1}},
+		{"popularity breaks ties", "shin", 0, []int{4, 5}},
+		{"word inside title", "titan", 0, []int{4}},
+		{"long vowel folded", "Kyoujin", 0, []int{4}},
+		{"limit", "shin", 1, []int{4}},
+		{"no match", "zzz", 0, []int{}},
+		{"empty prefix", " ", 0, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := []int{}
+			for _, suggestion := range s.Suggest(tt.prefix, tt.limit) {
+				ids = append(ids, suggestion.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("Suggest(%q) = %v, want %v", tt.prefix, ids, tt.want)
+			}
+		})
+	}
+}
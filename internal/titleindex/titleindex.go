@@ -0,0 +1,236 @@
+package titleindex
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxCandidates bounds how many documents sharing trigrams with the query
+// are scored exactly.
+const maxCandidates = 200
+
+// longVowels collapses the romanizations of long vowels, so "Kyōjin",
+// "Kyoujin" and "Kyoojin" all normalize to "kyojin".
+var longVowels = strings.NewReplacer("ou", "o", "oo", "o", "uu", "u")
+
+type Match struct {
+	ID int
+	// Title is the stored title that matched best.
+	Title string
+	// Confidence is in [0, 1]; 1 means the normalized titles are equal.
+	Confidence float64
+}
+
+type entry struct {
+	id    int
+	title string
+	key   string
+	grams map[string]struct{}
+}
+
+// Index resolves free-form names to documents by fuzzy title matching.
+// Every document can have several titles; the best matching one counts.
+type Index struct {
+	mu      sync.RWMutex
+	entries map[int][]*entry
+	grams   map[string]map[*entry]struct{}
+}
+
+func New() *Index {
+	return &Index{
+		entries: make(map[int][]*entry),
+		grams:   make(map[string]map[*entry]struct{}),
+	}
+}
+
+// Normalize folds case, strips diacritics and macrons, turns punctuation
+// into spaces and collapses long-vowel spellings.
+func Normalize(s string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+			space = false
+		case !space:
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return longVowels.Replace(strings.TrimSpace(b.String()))
+}
+
+// Add indexes or re-indexes the titles of the document with the given ID.
+// Empty titles are ignored.
+func (x *Index) Add(id int, titles ...string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(id)
+	seen := make(map[string]bool)
+	for _, title := range titles {
+		key := Normalize(title)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		e := &entry{id: id, title: title, key: key, grams: trigrams(key)}
+		x.entries[id] = append(x.entries[id], e)
+		for g := range e.grams {
+			set, ok := x.grams[g]
+			if !ok {
+				set = make(map[*entry]struct{})
+				x.grams[g] = set
+			}
+			set[e] = struct{}{}
+		}
+	}
+}
+
+func (x *Index) Remove(id int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+func (x *Index) remove(id int) {
+	for _, e := range x.entries[id] {
+		for g := range e.grams {
+			delete(x.grams[g], e)
+			if len(x.grams[g]) == 0 {
+				delete(x.grams, g)
+			}
+		}
+	}
+	delete(x.entries, id)
+}
+
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// Search returns up to limit documents whose titles match name with at
+// least minConfidence, best first.
+func (x *Index) Search(name string, limit int, minConfidence float64) []Match {
+	key := Normalize(name)
+	if key == "" {
+		return nil
+	}
+	grams := trigrams(key)
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	shared := make(map[*entry]int)
+	for g := range grams {
+		for e := range x.grams[g] {
+			shared[e]++
+		}
+	}
+
+	candidates := make([]*entry, 0, len(shared))
+	for e := range shared {
+		candidates = append(candidates, e)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if shared[candidates[i]] != shared[candidates[j]] {
+			return shared[candidates[i]] > shared[candidates[j]]
+		}
+		return candidates[i].id < candidates[j].id
+	})
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+
+	best := make(map[int]Match)
+	for _, e := range candidates {
+		confidence := similarity(key, grams, e)
+		if confidence < minConfidence {
+			continue
+		}
+		if current, ok := best[e.id]; !ok || confidence > current.Confidence {
+			best[e.id] = Match{ID: e.id, Title: e.title, Confidence: confidence}
+		}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for _, m := range best {
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Confidence != matches[j].Confidence {
+			return matches[i].Confidence > matches[j].Confidence
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// similarity is the larger of trigram Dice similarity and normalized edit
+// distance. A query contained in the title as whole words, such as
+// "naruto" in "naruto shippuden", scores at least 0.7.
+func similarity(key string, grams map[string]struct{}, e *entry) float64 {
+	if key == e.key {
+		return 1
+	}
+
+	var common int
+	for g := range grams {
+		if _, ok := e.grams[g]; ok {
+			common++
+		}
+	}
+	dice := 2 * float64(common) / float64(len(grams)+len(e.grams))
+
+	q, t := []rune(key), []rune(e.key)
+	edit := 1 - float64(levenshtein(q, t))/float64(max(len(q), len(t)))
+
+	score := max(dice, edit)
+	if strings.Contains(" "+e.key+" ", " "+key+" ") {
+		score = max(score, 0.7+0.3*float64(len(q))/float64(len(t)))
+	}
+	return min(score, 0.99)
+}
+
+func trigrams(key string) map[string]struct{} {
+	padded := []rune("  " + key + " ")
+	grams := make(map[string]struct{}, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		grams[string(padded[i:i+3])] = struct{}{}
+	}
+	return grams
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
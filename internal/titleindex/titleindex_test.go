@@ -0,0 +1,97 @@
+package titleindex
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Shingeki no Kyojin", "shingeki no kyojin"},
+		{"Shingeki no Kyōjin", "shingeki no kyojin"},
+		{"Shingeki no Kyoujin", "shingeki no kyojin"},
+		{"NARUTO: Shippūden", "naruto shippuden"},
+		{"Naruto Shippuuden", "naruto shippuden"},
+		{"Pokémon", "pokemon"},
+		{"  Steins;Gate  ", "steins gate"},
+		{"Re:ZERO -Starting Life in Another World-", "re zero starting life in another world"},
+		{"!!!", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	x := New()
+	x.Add(16498, "Shingeki no Kyojin", "Attack on Titan")
+	x.Add(1735, "Naruto: Shippuden", "Naruto Shippuden")
+	x.Add(20, "NARUTO", "Naruto")
+	x.Add(527, "Pokémon", "Pokémon")
+	x.Add(9253, "Steins;Gate", "")
+	x.Add(21, "ONE PIECE", "One Piece")
+
+	tests := []struct {
+		name           string
+		query          string
+		minConfidence  float64
+		wantID         int
+		wantConfidence float64
+	}{
+		{"exact", "Attack on Titan", 0.5, 16498, 1},
+		{"long vowel spelling", "Shingeki no Kyoujin", 0.5, 16498, 1},
+		{"macron", "Naruto Shippūden", 0.5, 1735, 1},
+		{"diacritics dropped", "pokemon", 0.5, 527, 1},
+		{"punctuation ignored", "steins gate", 0.5, 9253, 1},
+		{"typo", "Atack on Titan", 0.8, 16498, 0.9},
+		{"whole title beats containing title", "naruto", 0.5, 20, 1},
+		{"missing word", "shingeki kyojin", 0.6, 16498, 0.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := x.Search(tt.query, 1, tt.minConfidence)
+			if len(matches) == 0 {
+				t.Fatalf("Search(%q) found nothing", tt.query)
+			}
+			if m := matches[0]; m.ID != tt.wantID || m.Confidence < tt.wantConfidence {
+				t.Errorf("Search(%q) = %+v, want id %d with confidence at least %.2f", tt.query, m, tt.wantID, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestSearchThresholdAndRemove(t *testing.T) {
+	x := New()
+	x.Add(1, "Cowboy Bebop")
+	x.Add(2, "Space Dandy")
+
+	tests := []struct {
+		name          string
+		query         string
+		minConfidence float64
+		wantMatches   int
+	}{
+		{"unrelated", "Mushishi", 0.5, 0},
+		{"below threshold", "Cowboy", 0.95, 0},
+		{"contained word", "Cowboy", 0.7, 1},
+		{"empty after normalizing", "?!", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := x.Search(tt.query, 0, tt.minConfidence); len(got) != tt.wantMatches {
+				t.Errorf("Search(%q) = %+v, want %d matches", tt.query, got, tt.wantMatches)
+			}
+		})
+	}
+
+	x.Remove(1)
+	if got := x.Search("Cowboy Bebop", 0, 0.5); len(got) != 0 {
+		t.Errorf("Search after Remove = %+v, want none", got)
+	}
+	if x.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", x.Len())
+	}
+}
@@ -0,0 +1,248 @@
+package utils
+
+import (
+	"anime/internal/models"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	aniListURL = "https://graphql.anilist.co"
+
+	// DefaultAniListRateLimit is AniList's documented requests per minute.
+	// The API has run in a degraded mode of 30 per minute before; the
+	// X-RateLimit headers correct the budget either way.
+	DefaultAniListRateLimit = 90
+
+	aniListBurst      = 5
+	aniListMaxRetries = 5
+	aniListBaseDelay  = time.Second
+	aniListMaxDelay   = 30 * time.Second
+)
+
+// AniListClient posts GraphQL requests to AniList. Every request should go
+// through one client, so concurrent ingestion and sync jobs share its rate
+// limit. A token bucket spaces requests to perMinute, 429 responses pause
+// every caller until Retry-After has passed, and network errors and 5xx
+// responses are retried with jittered exponential backoff.
+type AniListClient struct {
+	// Endpoint is the GraphQL URL requests are posted to, AniList's API
+	// unless overridden.
+	Endpoint string
+
+	client *http.Client
+
+	mu sync.Mutex
+	// configured is the rate NewAniListClient was given; rate is lowered
+	// below it while AniList reports a smaller X-RateLimit-Limit.
+	configured float64
+	rate       float64 // tokens per second
+	burst      float64
+	tokens     float64
+	last       time.Time
+	// pausedUntil is set from Retry-After, or when AniList reports the
+	// remaining budget is exhausted.
+	pausedUntil time.Time
+	limit       int
+	remaining   int
+	requests    int64
+	retries     int64
+	rateLimited int64
+}
+
+func NewAniListClient(perMinute int) *AniListClient {
+	if perMinute <= 0 {
+		perMinute = DefaultAniListRateLimit
+	}
+	return &AniListClient{
+		Endpoint:   aniListURL,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		configured: float64(perMinute) / 60,
+		rate:       float64(perMinute) / 60,
+		burst:      aniListBurst,
+		tokens:     aniListBurst,
+		last:       time.Now(),
+		limit:      perMinute,
+		remaining:  -1,
+	}
+}
+
+// Post sends body to the AniList GraphQL endpoint and returns the response
+// body of the first successful attempt.
+func (c *AniListClient) Post(ctx context.Context, body []byte) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= aniListMaxRetries; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		respBody, retry, err := c.do(ctx, body)
+		if err == nil {
+			return respBody, nil
+		}
+		if !retry {
+			return nil, err
+		}
+		lastErr = err
+
+		c.mu.Lock()
+		c.retries++
+		c.mu.Unlock()
+
+		if err := sleep(ctx, backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("anilist api error after %d retries: %w", aniListMaxRetries, lastErr)
+}
+
+// do makes a single request. retry reports whether a failed request may
+// succeed if sent again.
+func (c *AniListClient) do(ctx context.Context, body []byte) (respBody []byte, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	c.observe(resp)
+
+	respBody, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return respBody, false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, true, fmt.Errorf("anilist api error: %s: %s", resp.Status, respBody)
+	default:
+		return nil, false, fmt.Errorf("anilist api error: %s: %s", resp.Status, respBody)
+	}
+}
+
+// observe updates the budget from AniList's rate limit headers.
+func (c *AniListClient) observe(resp *http.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests++
+	c.rate = c.configured
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil && limit > 0 {
+		c.limit = limit
+		c.rate = min(c.configured, float64(limit)/60)
+	}
+	exhausted := false
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		c.remaining = remaining
+		c.tokens = min(c.tokens, float64(remaining))
+		exhausted = remaining == 0
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		c.rateLimited++
+		delay := resetDelay(resp.Header)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			delay = time.Duration(seconds) * time.Second
+		}
+		c.pausedUntil = time.Now().Add(delay)
+	case exhausted:
+		c.pausedUntil = time.Now().Add(resetDelay(resp.Header))
+	}
+}
+
+// wait blocks until a token is available and no pause is in effect.
+func (c *AniListClient) wait(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		now := time.Now()
+		c.tokens = min(c.burst, c.tokens+now.Sub(c.last).Seconds()*c.rate)
+		c.last = now
+
+		var delay time.Duration
+		switch {
+		case now.Before(c.pausedUntil):
+			delay = c.pausedUntil.Sub(now)
+		case c.tokens >= 1:
+			c.tokens--
+			c.mu.Unlock()
+			return nil
+		default:
+			delay = time.Duration((1 - c.tokens) / c.rate * float64(time.Second))
+		}
+		c.mu.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Budget reports the limiter state and what AniList last said about the
+// remaining budget.
+func (c *AniListClient) Budget() models.AniListBudget {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	budget := models.AniListBudget{
+		Limit:       c.limit,
+		Remaining:   c.remaining,
+		Tokens:      min(c.burst, c.tokens+now.Sub(c.last).Seconds()*c.rate),
+		Requests:    c.requests,
+		Retries:     c.retries,
+		RateLimited: c.rateLimited,
+	}
+	if now.Before(c.pausedUntil) {
+		budget.PausedUntil = c.pausedUntil.UTC()
+	}
+	return budget
+}
+
+// resetDelay reads X-RateLimit-Reset, a Unix timestamp, falling back to a
+// full minute.
+func resetDelay(header http.Header) time.Duration {
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if delay := time.Until(time.Unix(reset, 0)); delay > 0 {
+			return delay
+		}
+	}
+	return time.Minute
+}
+
+// backoff returns a random delay between half and all of aniListBaseDelay
+// doubled per attempt, capped at aniListMaxDelay.
+func backoff(attempt int) time.Duration {
+	ceiling := min(aniListMaxDelay, aniListBaseDelay<<attempt)
+	return ceiling/2 + rand.N(ceiling/2)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"anime/internal/models"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const emptyPage = `{"data":{"Page":{"pageInfo":{"total":0,"lastPage":1},"media":[]}}}`
+
+// response is one scripted reply of the fake AniList server.
+type response struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+func TestFetchAnimePage(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []response
+		wantErr      bool
+		wantRequests int64
+		minElapsed   time.Duration
+	}{
+		{"ok", []response{{200, nil, emptyPage}}, false, 1, 0},
+		{"graphql errors", []response{{200, nil, `{"data":null,"errors":[{"message":"Internal Server Error"}]}`}}, true, 1, 0},
+		{"client error not retried", []response{{400, nil, `{"errors":[{"message":"bad query"}]}`}}, true, 1, 0},
+		{"server error retried", []response{{503, nil, ""}, {200, nil, emptyPage}}, false, 2, 0},
+		{"retry after", []response{{429, map[string]string{"Retry-After": "1"}, ""}, {200, nil, emptyPage}}, false, 2, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := requests.Add(1)
+				resp := tt.responses[min(int(n), len(tt.responses))-1]
+				for k, v := range resp.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(resp.status)
+				fmt.Fprint(w, resp.body)
+			}))
+			defer server.Close()
+
+			c := NewAniListClient(6000)
+			c.Endpoint = server.URL
+
+			start := time.Now()
+			_, _, err := c.FetchAnimePage(context.Background(), models.AniListQuery{Page: 1, PerPage: 50})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("sent %d requests, want %d", got, tt.wantRequests)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("returned after %v, want at least %v", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     string
+		wantLimit int
+		wantRate  float64
+	}{
+		{"lower than configured", "30", 30, 0.5},
+		{"higher than configured", "600", 600, 1},
+		{"missing", "", 60, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewAniListClient(60)
+			resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+			if tt.limit != "" {
+				resp.Header.Set("X-RateLimit-Limit", tt.limit)
+			}
+			c.observe(resp)
+
+			if c.limit != tt.wantLimit || c.rate != tt.wantRate {
+				t.Errorf("limit %d, rate %v; want %d, %v", c.limit, c.rate, tt.wantLimit, tt.wantRate)
+			}
+		})
+	}
+}
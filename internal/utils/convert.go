@@ -1,6 +1,21 @@
 package utils
 
-import "anime/internal/models"
+import (
+	"anime/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+func EmbeddingText(title models.Title, description string, genres []string) string {
+	return title.Romaji + " " + title.English + " " + description + " " + strings.Join(genres, " ")
+}
+
+// ContentHash returns a hex SHA-256 digest of an embedding text.
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
 
 func ConvertResponseToAnime(resp models.AnimeResponse, embedding []float32) models.Anime {
 	return models.Anime{
@@ -9,6 +24,7 @@ func ConvertResponseToAnime(resp models.AnimeResponse, embedding []float32) mode
 		Description:  resp.Description,
 		Genres:       resp.Genres,
 		AverageScore: resp.AverageScore,
+		Popularity:   resp.Popularity,
 		Episodes:     resp.Episodes,
 		Duration:     resp.Duration,
 		Season:       resp.Season,
@@ -20,3 +36,45 @@ func ConvertResponseToAnime(resp models.AnimeResponse, embedding []float32) mode
 		Embedding:    embedding,
 	}
 }
+
+func ConvertAnimeToResponse(anime models.Anime) models.AnimeResponse {
+	return models.AnimeResponse{
+		ID:           anime.ID,
+		Title:        anime.Title,
+		Description:  anime.Description,
+		Genres:       anime.Genres,
+		AverageScore: anime.AverageScore,
+		Popularity:   anime.Popularity,
+		Episodes:     anime.Episodes,
+		Duration:     anime.Duration,
+		Season:       anime.Season,
+		SeasonYear:   anime.SeasonYear,
+		Status:       anime.Status,
+		Source:       anime.Source,
+		Studios:      anime.Studios,
+		CoverImage:   anime.CoverImage,
+	}
+}
+
+func ConvertScoredToRecc(scored models.ScoredAnime) models.AnimeReccResponse {
+	anime := scored.Anime
+	return models.AnimeReccResponse{
+		ID:           anime.ID,
+		Title:        anime.Title,
+		Description:  anime.Description,
+		Genres:       anime.Genres,
+		AverageScore: anime.AverageScore,
+		Popularity:   anime.Popularity,
+		Episodes:     anime.Episodes,
+		Duration:     anime.Duration,
+		Season:       anime.Season,
+		SeasonYear:   anime.SeasonYear,
+		Status:       anime.Status,
+		Source:       anime.Source,
+		Studios:      anime.Studios,
+		CoverImage:   anime.CoverImage,
+		Score:        scored.Score,
+		Components:   scored.Components,
+		Explanation:  scored.Explanation,
+	}
+}
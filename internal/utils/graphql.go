@@ -2,18 +2,33 @@ package utils
 
 import (
 	"anime/internal/models"
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
 )
 
-func GraphQLAPIRequest(page int64, perPage int64) ([]models.AnimeResponse, error) {
+func (c *AniListClient) GraphQLAPIRequest(ctx context.Context, page int64, perPage int64) ([]models.AnimeResponse, error) {
+	animes, _, err := c.FetchAnimePage(ctx, models.AniListQuery{Page: page, PerPage: perPage})
+	return animes, err
+}
+
+// FetchAnimePage fetches one page of AniList anime together with its
+// pageInfo. Unset filters in q are sent as null, which AniList ignores.
+func (c *AniListClient) FetchAnimePage(ctx context.Context, q models.AniListQuery) ([]models.AnimeResponse, models.PageInfo, error) {
 	query := `
-	query ($page: Int, $perPage: Int) {
+	query ($page: Int, $perPage: Int, $sort: [MediaSort], $formats: [MediaFormat],
+	       $startedAfter: FuzzyDateInt, $startedBefore: FuzzyDateInt, $popularityAbove: Int) {
 	  Page(page: $page, perPage: $perPage) {
-		media(type: ANIME, sort: POPULARITY_DESC) {
+		pageInfo {
+		  total
+		  currentPage
+		  lastPage
+		  hasNextPage
+		  perPage
+		}
+		media(type: ANIME, sort: $sort, format_in: $formats, startDate_greater: $startedAfter,
+		      startDate_lesser: $startedBefore, popularity_greater: $popularityAbove) {
 		  id
 		  title {
 			romaji
@@ -22,6 +37,8 @@ func GraphQLAPIRequest(page int64, perPage int64) ([]models.AnimeResponse, error
 		  description
 		  genres
 		  averageScore
+		  popularity
+		  updatedAt
 		  episodes
 		  duration
 		  season
@@ -40,9 +57,28 @@ func GraphQLAPIRequest(page int64, perPage int64) ([]models.AnimeResponse, error
 	  }
 	}`
 
+	sort := q.Sort
+	if sort == "" {
+		sort = "POPULARITY_DESC"
+	}
 	variables := map[string]any{
-		"page":    page,
-		"perPage": perPage,
+		"page":    q.Page,
+		"perPage": q.PerPage,
+		"sort":    []string{sort},
+	}
+	if len(q.Formats) > 0 {
+		variables["formats"] = q.Formats
+	}
+	// Start dates are FuzzyDateInt values (YYYYMMDD); a year alone is
+	// YYYY0000, so these bounds include the whole first and last year.
+	if q.MinYear > 0 {
+		variables["startedAfter"] = q.MinYear * 10000
+	}
+	if q.MaxYear > 0 {
+		variables["startedBefore"] = (q.MaxYear + 1) * 10000
+	}
+	if q.MinPopularity > 0 {
+		variables["popularityAbove"] = q.MinPopularity - 1
 	}
 
 	reqBody := models.GraphQLRequest{
@@ -51,30 +87,29 @@ func GraphQLAPIRequest(page int64, perPage int64) ([]models.AnimeResponse, error
 	}
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, err
+		return nil, models.PageInfo{}, err
 	}
 
-	resp, err := http.Post("https://graphql.anilist.co", "application/json", bytes.NewBuffer(jsonBody))
+	body, err := c.Post(ctx, jsonBody)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("anilist api error: %s", string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+		return nil, models.PageInfo{}, err
 	}
 
 	var result struct {
-		Data models.AnimeAPIResponse `json:"data"`
+		Data   models.AnimeAPIResponse `json:"data"`
+		Errors []models.GraphQLError   `json:"errors"`
 	}
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+		return nil, models.PageInfo{}, err
+	}
+	// AniList can answer 200 with errors and no data; treating that as an
+	// empty page would let ingestion and sync skip it.
+	if len(result.Errors) > 0 {
+		messages := make([]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			messages = append(messages, e.Message)
+		}
+		return nil, models.PageInfo{}, fmt.Errorf("anilist graphql error: %s", strings.Join(messages, "; "))
 	}
 
 	var animes []models.AnimeResponse
@@ -90,6 +125,7 @@ func GraphQLAPIRequest(page int64, perPage int64) ([]models.AnimeResponse, error
 			Description:  m.Description,
 			Genres:       m.Genres,
 			AverageScore: m.AverageScore,
+			Popularity:   m.Popularity,
 			Episodes:     m.Episodes,
 			Duration:     m.Duration,
 			Season:       m.Season,
@@ -98,8 +134,9 @@ func GraphQLAPIRequest(page int64, perPage int64) ([]models.AnimeResponse, error
 			Source:       m.Source,
 			Studios:      studios,
 			CoverImage:   models.CoverImage{Large: m.CoverImage.Large},
+			UpdatedAt:    m.UpdatedAt,
 		})
 	}
 
-	return animes, nil
+	return animes, result.Data.Page.PageInfo, nil
 }
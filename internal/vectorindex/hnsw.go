@@ -0,0 +1,498 @@
+package vectorindex
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+var ErrDimensionMismatch = errors.New("vector dimension does not match index")
+
+const (
+	// Compaction is due once tombstones make up maxTombstoneRatio of the
+	// nodes, and there are at least minCompactTombstones of them.
+	maxTombstoneRatio    = 0.2
+	minCompactTombstones = 64
+)
+
+type Config struct {
+	// M is the number of neighbours kept per node on the upper layers;
+	// layer 0 keeps 2*M.
+	M int
+	// EfConstruction is the candidate list size used while inserting.
+	EfConstruction int
+	// EfSearch is the default candidate list size used while searching.
+	EfSearch int
+	Seed     int64
+}
+
+func DefaultConfig() Config {
+	return Config{M: 16, EfConstruction: 200, EfSearch: 64, Seed: 42}
+}
+
+type Result struct {
+	ID    int
+	Score float64
+}
+
+type node struct {
+	ID        int
+	Stamp     int64
+	Vector    []float32
+	Neighbors [][]int32
+	Deleted   bool
+}
+
+// HNSW is an in-process hierarchical navigable small world graph for
+// approximate nearest neighbour search by cosine similarity.
+type HNSW struct {
+	mu        sync.RWMutex
+	cfg       Config
+	dims      int
+	nodes     []node
+	ids       map[int]int32
+	deleted   int
+	entry     int32
+	maxLevel  int
+	levelMult float64
+	rng       *rand.Rand
+}
+
+func New(cfg Config) *HNSW {
+	defaults := DefaultConfig()
+	if cfg.M <= 1 {
+		cfg.M = defaults.M
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = defaults.EfConstruction
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = defaults.EfSearch
+	}
+
+	return &HNSW{
+		cfg:       cfg,
+		ids:       make(map[int]int32),
+		entry:     -1,
+		levelMult: 1 / math.Log(float64(cfg.M)),
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+	}
+}
+
+func (h *HNSW) Config() Config {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.cfg
+}
+
+func (h *HNSW) SetEfSearch(ef int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ef > 0 {
+		h.cfg.EfSearch = ef
+	}
+}
+
+func (h *HNSW) Dimensions() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.dims
+}
+
+// Len returns the number of live (non-deleted) vectors in the index.
+func (h *HNSW) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.ids)
+}
+
+// Stamp returns the stamp recorded when id was inserted, so callers can
+// detect documents whose embedding changed outside the index.
+func (h *HNSW) Stamp(id int) (int64, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	idx, ok := h.ids[id]
+	if !ok {
+		return 0, false
+	}
+	return h.nodes[idx].Stamp, true
+}
+
+// Insert adds or replaces the vector stored for id. Replaced vectors are
+// tombstoned and still used for graph traversal until Compact.
+func (h *HNSW) Insert(id int, vector []float32, stamp int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.dims == 0 {
+		h.dims = len(vector)
+	}
+	if len(vector) != h.dims || len(vector) == 0 {
+		return fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(vector), h.dims)
+	}
+
+	if old, ok := h.ids[id]; ok {
+		h.nodes[old].Deleted = true
+		h.deleted++
+	}
+
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+	idx := int32(len(h.nodes))
+	h.nodes = append(h.nodes, node{
+		ID:        id,
+		Stamp:     stamp,
+		Vector:    normalize(vector),
+		Neighbors: make([][]int32, level+1),
+	})
+	h.ids[id] = idx
+
+	if h.entry < 0 {
+		h.entry = idx
+		h.maxLevel = level
+		return nil
+	}
+
+	q := h.nodes[idx].Vector
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedy(q, ep, l)
+	}
+
+	entryPoints := []int32{ep}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(q, entryPoints, h.cfg.EfConstruction, l)
+		neighbours := h.selectNeighbours(candidates, h.maxNeighbours(l))
+		h.nodes[idx].Neighbors[l] = neighbours
+
+		for _, n := range neighbours {
+			h.connect(n, idx, l)
+		}
+
+		entryPoints = entryPoints[:0]
+		for _, c := range candidates {
+			entryPoints = append(entryPoints, c.idx)
+		}
+	}
+
+	if level > h.maxLevel {
+		h.entry = idx
+		h.maxLevel = level
+	}
+	return nil
+}
+
+// Vector returns the normalized vector stored for id.
+func (h *HNSW) Vector(id int) ([]float32, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	idx, ok := h.ids[id]
+	if !ok {
+		return nil, false
+	}
+	return h.nodes[idx].Vector, true
+}
+
+// Vectors returns the normalized vectors of all live entries keyed by ID.
+func (h *HNSW) Vectors() map[int][]float32 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	vectors := make(map[int][]float32, len(h.ids))
+	for id, idx := range h.ids {
+		vectors[id] = h.nodes[idx].Vector
+	}
+	return vectors
+}
+
+func (h *HNSW) Delete(id int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if idx, ok := h.ids[id]; ok {
+		h.nodes[idx].Deleted = true
+		h.deleted++
+		delete(h.ids, id)
+	}
+}
+
+// IDs returns the IDs of all live entries.
+func (h *HNSW) IDs() []int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]int, 0, len(h.ids))
+	for id := range h.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Tombstones returns the number of deleted or replaced nodes still kept in
+// the graph.
+func (h *HNSW) Tombstones() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.deleted
+}
+
+// NeedsCompaction reports whether enough tombstones have piled up that
+// Compact is worth its cost.
+func (h *HNSW) NeedsCompaction() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.deleted >= minCompactTombstones && float64(h.deleted) >= maxTombstoneRatio*float64(len(h.nodes))
+}
+
+// Compact rebuilds the graph from the live vectors, dropping tombstones.
+// The new graph is built without blocking searches; inserts and deletes
+// made meanwhile are replayed onto it before it replaces the old one.
+func (h *HNSW) Compact() {
+	h.mu.RLock()
+	cfg := h.cfg
+	seen := len(h.nodes)
+	live := make([]node, 0, len(h.ids))
+	for _, n := range h.nodes {
+		if !n.Deleted {
+			live = append(live, n)
+		}
+	}
+	h.mu.RUnlock()
+
+	fresh := New(cfg)
+	for _, n := range live {
+		fresh.Insert(n.ID, n.Vector, n.Stamp)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, n := range h.nodes[seen:] {
+		if !n.Deleted {
+			fresh.Insert(n.ID, n.Vector, n.Stamp)
+		}
+	}
+	for _, n := range live {
+		if _, ok := h.ids[n.ID]; !ok {
+			fresh.Delete(n.ID)
+		}
+	}
+
+	h.dims = max(h.dims, fresh.dims)
+	h.nodes, h.ids, h.deleted = fresh.nodes, fresh.ids, fresh.deleted
+	h.entry, h.maxLevel = fresh.entry, fresh.maxLevel
+}
+
+// Search returns the k nearest live vectors to query, best first. ef widens
+// the candidate list; values below the configured EfSearch are raised to it.
+func (h *HNSW) Search(query []float32, k int, ef int) ([]Result, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.entry < 0 || k <= 0 {
+		return nil, nil
+	}
+	if len(query) != h.dims {
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(query), h.dims)
+	}
+
+	ef = max(ef, h.cfg.EfSearch, k)
+	q := normalize(query)
+
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedy(q, ep, l)
+	}
+
+	candidates := h.searchLayer(q, []int32{ep}, ef, 0)
+	results := make([]Result, 0, k)
+	for _, c := range candidates {
+		n := h.nodes[c.idx]
+		if n.Deleted {
+			continue
+		}
+		results = append(results, Result{ID: n.ID, Score: 1 - c.dist})
+		if len(results) == k {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (h *HNSW) maxNeighbours(level int) int {
+	if level == 0 {
+		return 2 * h.cfg.M
+	}
+	return h.cfg.M
+}
+
+func (h *HNSW) distance(q []float32, idx int32) float64 {
+	return 1 - dot(q, h.nodes[idx].Vector)
+}
+
+func (h *HNSW) greedy(q []float32, ep int32, level int) int32 {
+	best := h.distance(q, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, n := range h.nodes[ep].Neighbors[level] {
+			if d := h.distance(q, n); d < best {
+				best, ep, changed = d, n, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns up to ef candidates closest to q on the given layer,
+// sorted by ascending distance.
+func (h *HNSW) searchLayer(q []float32, entryPoints []int32, ef int, level int) []candidate {
+	visited := make(map[int32]struct{}, ef*4)
+	near := &minHeap{}
+	far := &maxHeap{}
+
+	for _, ep := range entryPoints {
+		if _, ok := visited[ep]; ok {
+			continue
+		}
+		visited[ep] = struct{}{}
+		c := candidate{idx: ep, dist: h.distance(q, ep)}
+		heap.Push(near, c)
+		heap.Push(far, c)
+		if far.Len() > ef {
+			heap.Pop(far)
+		}
+	}
+
+	for near.Len() > 0 {
+		c := heap.Pop(near).(candidate)
+		if far.Len() >= ef && c.dist > (*far)[0].dist {
+			break
+		}
+
+		for _, n := range h.nodes[c.idx].Neighbors[level] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+
+			d := h.distance(q, n)
+			if far.Len() < ef || d < (*far)[0].dist {
+				heap.Push(near, candidate{idx: n, dist: d})
+				heap.Push(far, candidate{idx: n, dist: d})
+				if far.Len() > ef {
+					heap.Pop(far)
+				}
+			}
+		}
+	}
+
+	results := []candidate(*far)
+	sort.Slice(results, func(i, j int) bool { return results[i].dist < results[j].dist })
+	return results
+}
+
+// selectNeighbours applies the HNSW neighbour heuristic: a candidate is kept
+// only if it is closer to the new node than to any neighbour already kept,
+// which spreads links across clusters. Remaining slots are filled with the
+// closest pruned candidates.
+func (h *HNSW) selectNeighbours(candidates []candidate, m int) []int32 {
+	selected := make([]int32, 0, m)
+	var pruned []int32
+
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		keep := true
+		for _, s := range selected {
+			if 1-dot(h.nodes[c.idx].Vector, h.nodes[s].Vector) < c.dist {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c.idx)
+		} else {
+			pruned = append(pruned, c.idx)
+		}
+	}
+
+	for _, p := range pruned {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, p)
+	}
+	return selected
+}
+
+func (h *HNSW) connect(from, to int32, level int) {
+	neighbours := append(h.nodes[from].Neighbors[level], to)
+	limit := h.maxNeighbours(level)
+	if len(neighbours) > limit {
+		v := h.nodes[from].Vector
+		candidates := make([]candidate, 0, len(neighbours))
+		for _, n := range neighbours {
+			candidates = append(candidates, candidate{idx: n, dist: h.distance(v, n)})
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+		neighbours = h.selectNeighbours(candidates, limit)
+	}
+	h.nodes[from].Neighbors[level] = neighbours
+}
+
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	scale := 1 / math.Sqrt(norm)
+	for i, x := range v {
+		out[i] = float32(float64(x) * scale)
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+type candidate struct {
+	idx  int32
+	dist float64
+}
+
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
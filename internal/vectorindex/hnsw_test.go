@@ -0,0 +1,138 @@
+package vectorindex
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func randomVectors(rng *rand.Rand, n, dims int) map[int][]float32 {
+	vectors := make(map[int][]float32, n)
+	for id := 1; id <= n; id++ {
+		v := make([]float32, dims)
+		for i := range v {
+			v[i] = float32(rng.NormFloat64())
+		}
+		vectors[id] = v
+	}
+	return vectors
+}
+
+func buildIndex(t *testing.T, cfg Config, vectors map[int][]float32) *HNSW {
+	t.Helper()
+	h := New(cfg)
+	for id := 1; id <= len(vectors); id++ {
+		if err := h.Insert(id, vectors[id], 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return h
+}
+
+func queries(rng *rand.Rand, n, dims int) [][]float32 {
+	var qs [][]float32
+	for _, v := range randomVectors(rng, n, dims) {
+		qs = append(qs, v)
+	}
+	return qs
+}
+
+func TestRecallAgainstBruteForce(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		k, ef      int
+		wantRecall float64
+	}{
+		{"default", DefaultConfig(), 10, 0, 0.9},
+		{"small graph", Config{M: 8, EfConstruction: 100, EfSearch: 32, Seed: 7}, 10, 64, 0.9},
+		{"wide search", Config{M: 16, EfConstruction: 200, EfSearch: 64, Seed: 1}, 10, 200, 0.98},
+		{"top 1", DefaultConfig(), 1, 0, 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(tt.cfg.Seed))
+			vectors := randomVectors(rng, 1000, 24)
+			h := buildIndex(t, tt.cfg, vectors)
+
+			report, err := MeasureRecall(h, vectors, queries(rng, 50, 24), tt.k, tt.ef)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Recall < tt.wantRecall {
+				t.Errorf("recall@%d = %.3f, want at least %.2f", tt.k, report.Recall, tt.wantRecall)
+			}
+		})
+	}
+}
+
+func TestDeleteAndCompact(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	vectors := randomVectors(rng, 600, 24)
+	h := buildIndex(t, DefaultConfig(), vectors)
+
+	for id := 1; id <= 200; id++ {
+		h.Delete(id)
+		delete(vectors, id)
+	}
+	if got := h.Tombstones(); got != 200 {
+		t.Fatalf("Tombstones() = %d, want 200", got)
+	}
+	if !h.NeedsCompaction() {
+		t.Fatal("NeedsCompaction() = false with a third of the nodes deleted")
+	}
+
+	h.Compact()
+	if got := h.Tombstones(); got != 0 {
+		t.Errorf("Tombstones() after Compact = %d, want 0", got)
+	}
+	if got := h.Len(); got != 400 {
+		t.Errorf("Len() after Compact = %d, want 400", got)
+	}
+
+	report, err := MeasureRecall(h, vectors, queries(rng, 50, 24), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Recall < 0.9 {
+		t.Errorf("recall@10 after Compact = %.3f, want at least 0.9", report.Recall)
+	}
+}
+
+func TestSaveLoadSkipsTombstones(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	vectors := randomVectors(rng, 500, 24)
+	h := buildIndex(t, DefaultConfig(), vectors)
+	for id := 1; id <= 500; id += 5 {
+		h.Delete(id)
+		delete(vectors, id)
+	}
+
+	path := filepath.Join(t.TempDir(), "index.gob")
+	if err := h.SaveFile(path, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, model, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if model != "hash" {
+		t.Errorf("model = %q, want %q", model, "hash")
+	}
+	if loaded.Len() != len(vectors) || loaded.Tombstones() != 0 {
+		t.Errorf("loaded %d nodes with %d tombstones, want %d and 0", loaded.Len(), loaded.Tombstones(), len(vectors))
+	}
+	if _, ok := loaded.Vector(1); ok {
+		t.Error("deleted id 1 was saved")
+	}
+
+	report, err := MeasureRecall(loaded, vectors, queries(rng, 50, 24), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Recall < 0.9 {
+		t.Errorf("recall@10 after reload = %.3f, want at least 0.9", report.Recall)
+	}
+}
@@ -0,0 +1,154 @@
+package vectorindex
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+type snapshot struct {
+	Model    string
+	Config   Config
+	Dims     int
+	Nodes    []node
+	Entry    int32
+	MaxLevel int
+}
+
+// SaveFile writes the live nodes of the index to path atomically, tagged
+// with the embedding model its vectors came from.
+func (h *HNSW) SaveFile(path string, model string) error {
+	h.mu.RLock()
+	snap := h.liveSnapshot()
+	snap.Model = model
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		h.mu.RUnlock()
+		return fmt.Errorf("create index file error: %w", err)
+	}
+	err = gob.NewEncoder(tmp).Encode(snap)
+	h.mu.RUnlock()
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("encode index error: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// liveSnapshot copies the graph without tombstones. A link to a tombstone
+// is replaced by the tombstone's live neighbours, so the graph stays
+// connected. If the entry point was deleted, the live node on the highest
+// layer takes its place.
+func (h *HNSW) liveSnapshot() snapshot {
+	snap := snapshot{Config: h.cfg, Dims: h.dims, Entry: -1}
+	if h.deleted == 0 {
+		snap.Nodes, snap.Entry, snap.MaxLevel = h.nodes, h.entry, h.maxLevel
+		return snap
+	}
+
+	remap := make(map[int32]int32, len(h.ids))
+	for i, n := range h.nodes {
+		if !n.Deleted {
+			remap[int32(i)] = int32(len(remap))
+		}
+	}
+
+	snap.Nodes = make([]node, 0, len(remap))
+	for i, n := range h.nodes {
+		if n.Deleted {
+			continue
+		}
+		neighbours := make([][]int32, len(n.Neighbors))
+		for l := range n.Neighbors {
+			for _, link := range h.liveLinks(int32(i), l) {
+				neighbours[l] = append(neighbours[l], remap[link])
+			}
+		}
+		n.Neighbors = neighbours
+		snap.Nodes = append(snap.Nodes, n)
+
+		if top := len(n.Neighbors) - 1; snap.Entry < 0 || top > snap.MaxLevel {
+			snap.Entry, snap.MaxLevel = remap[int32(i)], top
+		}
+	}
+	if to, ok := remap[h.entry]; ok {
+		snap.Entry, snap.MaxLevel = to, h.maxLevel
+	}
+	return snap
+}
+
+// liveLinks returns the neighbours of idx on level with each tombstone
+// replaced by its own live neighbours, pruned back to the level's limit.
+func (h *HNSW) liveLinks(idx int32, level int) []int32 {
+	links := h.nodes[idx].Neighbors[level]
+	if !slices.ContainsFunc(links, func(n int32) bool { return h.nodes[n].Deleted }) {
+		return links
+	}
+
+	seen := map[int32]bool{idx: true}
+	var candidates []candidate
+	add := func(n int32) {
+		if !seen[n] && !h.nodes[n].Deleted {
+			seen[n] = true
+			candidates = append(candidates, candidate{idx: n, dist: h.distance(h.nodes[idx].Vector, n)})
+		}
+	}
+	for _, n := range links {
+		if !h.nodes[n].Deleted {
+			add(n)
+			continue
+		}
+		if level < len(h.nodes[n].Neighbors) {
+			for _, nn := range h.nodes[n].Neighbors[level] {
+				add(nn)
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+	return h.selectNeighbours(candidates, h.maxNeighbours(level))
+}
+
+// LoadFile reads an index written by SaveFile and returns it together with
+// the embedding model it was built for.
+func LoadFile(path string) (*HNSW, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		return nil, "", fmt.Errorf("decode index error: %w", err)
+	}
+
+	h := &HNSW{
+		cfg:       snap.Config,
+		dims:      snap.Dims,
+		nodes:     snap.Nodes,
+		ids:       make(map[int]int32, len(snap.Nodes)),
+		entry:     snap.Entry,
+		maxLevel:  snap.MaxLevel,
+		levelMult: 1 / math.Log(float64(snap.Config.M)),
+		rng:       rand.New(rand.NewSource(snap.Config.Seed + int64(len(snap.Nodes)))),
+	}
+	for i, n := range h.nodes {
+		if n.Deleted {
+			h.deleted++
+		} else {
+			h.ids[n.ID] = int32(i)
+		}
+	}
+	return h, snap.Model, nil
+}
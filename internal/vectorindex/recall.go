@@ -0,0 +1,72 @@
+package vectorindex
+
+import (
+	"sort"
+	"time"
+)
+
+type RecallReport struct {
+	Queries      int           `json:"queries"`
+	K            int           `json:"k"`
+	EfSearch     int           `json:"efSearch"`
+	Recall       float64       `json:"recall"`
+	IndexLatency time.Duration `json:"indexLatency"`
+	BruteLatency time.Duration `json:"bruteLatency"`
+}
+
+// BruteForce returns the exact k nearest vectors to query by cosine
+// similarity, as the ground truth for MeasureRecall.
+func BruteForce(vectors map[int][]float32, query []float32, k int) []Result {
+	q := normalize(query)
+	results := make([]Result, 0, len(vectors))
+	for id, v := range vectors {
+		results = append(results, Result{ID: id, Score: dot(q, normalize(v))})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// MeasureRecall compares index results for each query against an exact
+// brute-force scan over vectors and reports mean recall@k and mean latency.
+func MeasureRecall(h *HNSW, vectors map[int][]float32, queries [][]float32, k int, ef int) (RecallReport, error) {
+	report := RecallReport{Queries: len(queries), K: k, EfSearch: max(ef, h.Config().EfSearch)}
+	if len(queries) == 0 {
+		return report, nil
+	}
+
+	var hits, total int
+	var indexTime, bruteTime time.Duration
+	for _, q := range queries {
+		start := time.Now()
+		exact := BruteForce(vectors, q, k)
+		bruteTime += time.Since(start)
+
+		start = time.Now()
+		approx, err := h.Search(q, k, ef)
+		if err != nil {
+			return report, err
+		}
+		indexTime += time.Since(start)
+
+		found := make(map[int]struct{}, len(approx))
+		for _, r := range approx {
+			found[r.ID] = struct{}{}
+		}
+		for _, r := range exact {
+			if _, ok := found[r.ID]; ok {
+				hits++
+			}
+		}
+		total += len(exact)
+	}
+
+	if total > 0 {
+		report.Recall = float64(hits) / float64(total)
+	}
+	report.IndexLatency = indexTime / time.Duration(len(queries))
+	report.BruteLatency = bruteTime / time.Duration(len(queries))
+	return report, nil
+}